@@ -0,0 +1,225 @@
+package oibot
+
+import (
+	"context"
+)
+
+// Batch accumulates commands and sends them to the robot in a single write, so
+// that everything decided in one control tick reaches the robot together. Each
+// command is validated as it is added, and a command that fails validation is
+// not added. Whether each command is legal in the robot's mode is checked when
+// the batch is sent, taking into account mode changes earlier in the batch;
+// if any command is illegal, nothing is sent.
+//
+// The robot receives the commands back to back, without the guard time that
+// separately sent commands are given. The exception is a command that changes
+// the OI mode, which the robot needs time to act on: the batch is split after
+// it, and the rest is written once the pacing's ModeGuard has passed. A Batch
+// is not safe for concurrent use, but any number of batches may be sent
+// concurrently with other commands.
+type Batch struct {
+	o       *OIBot
+	command []encodedCommand
+	after   []func() // state to record once the batch is sent
+}
+
+func (o *OIBot) NewBatch() *Batch {
+	return &Batch{o: o}
+}
+
+func (b *Batch) add(code OpCode, args ...interface{}) error {
+	bin, err := b.o.Pack(args...)
+	if nil != err {
+		return err
+	}
+	b.command = append(b.command, encodedCommand{code: code, args: bin})
+	return nil
+}
+
+// Len returns the number of commands in the batch.
+func (b *Batch) Len() int {
+	return len(b.command)
+}
+
+// Bytes returns the batch exactly as it would be written to the robot, though
+// possibly over several writes.
+func (b *Batch) Bytes() []byte {
+	return joinCommands(b.command)
+}
+
+func (b *Batch) Reset() {
+	b.command, b.after = nil, nil
+}
+
+func (b *Batch) Send() error {
+	return b.SendContext(context.Background())
+}
+
+// SendContext writes the batch as a single job on the command queue and
+// empties it. An empty batch sends nothing. If ctx is done while waiting out a
+// mode change, the rest of the batch is not sent.
+func (b *Batch) SendContext(ctx context.Context) error {
+	if 0 == len(b.command) {
+		return nil
+	}
+	code := make([]OpCode, len(b.command))
+	for i, c := range b.command {
+		code[i] = c.code
+	}
+	part := b.parts()
+	err := b.o.queue.do(ctx, func(ctx context.Context) error {
+		// check the whole batch before any part of it goes out
+		if err := b.o.checkMode(code...); nil != err {
+			return err
+		}
+		for _, command := range part {
+			if err := b.o.transmit(ctx, command); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+	if nil != err {
+		return err
+	}
+	for _, fn := range b.after {
+		fn()
+	}
+	b.Reset()
+	return nil
+}
+
+// parts splits the batch after each command that changes the OI mode.
+func (b *Batch) parts() [][]encodedCommand {
+	var part [][]encodedCommand
+	start := 0
+	for i, c := range b.command {
+		if _, ok := nextMode[c.code]; ok {
+			part = append(part, b.command[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(b.command) {
+		part = append(part, b.command[start:])
+	}
+	return part
+}
+
+// =============================================================================
+
+// Write adds an arbitrary command, packing its arguments as OIBot.Write does.
+// Only the packing is validated.
+func (b *Batch) Write(code OpCode, buf ...interface{}) error {
+	return b.add(code, buf...)
+}
+
+func (b *Batch) Start() error {
+	return b.add(opcStart)
+}
+
+func (b *Batch) Safe() error {
+	return b.add(opcSafe)
+}
+
+func (b *Batch) Full() error {
+	return b.add(opcFull)
+}
+
+func (b *Batch) Drive(velocity int16, radius int16) error {
+	if err := validateDrive(velocity, radius); nil != err {
+		return err
+	}
+	if err := b.add(opcDrive, velocity, radius); nil != err {
+		return err
+	}
+	b.after = append(b.after, func() { b.o.setVelocity(driveVelocity(velocity, radius)) })
+	return nil
+}
+
+func (b *Batch) DriveStop() error {
+	return b.Drive(0, 0)
+}
+
+func (b *Batch) DriveWheels(rightVelocity int16, leftVelocity int16) error {
+	if err := validateDriveWheels(rightVelocity, leftVelocity); nil != err {
+		return err
+	}
+	if err := b.add(opcDriveWheels, rightVelocity, leftVelocity); nil != err {
+		return err
+	}
+	b.after = append(b.after, func() { b.o.setVelocity((rightVelocity + leftVelocity) / 2) })
+	return nil
+}
+
+func (b *Batch) DrivePWM(rightPWM int16, leftPWM int16) error {
+	if err := validateDrivePWM(rightPWM, leftPWM); nil != err {
+		return err
+	}
+	if err := b.add(opcDrivePWM, rightPWM, leftPWM); nil != err {
+		return err
+	}
+	b.after = append(b.after, func() { b.o.setVelocity((rightPWM + leftPWM) / 2) })
+	return nil
+}
+
+func (b *Batch) SetMotors(motors Motors) error {
+	if err := b.add(opcMotors, byte(motors)); nil != err {
+		return err
+	}
+	b.after = append(b.after, func() { b.o.setMotorPWM(motors.pwm()) })
+	return nil
+}
+
+func (b *Batch) PWMMotors(mainBrush int8, sideBrush int8, vacuum int8) error {
+	if err := validatePWMMotors(mainBrush, sideBrush, vacuum); nil != err {
+		return err
+	}
+	if err := b.add(opcPWMMotors, mainBrush, sideBrush, vacuum); nil != err {
+		return err
+	}
+	b.after = append(b.after, func() { b.o.setMotorPWM(mainBrush, sideBrush, vacuum) })
+	return nil
+}
+
+func (b *Batch) MotorsOff() error {
+	return b.SetMotors(0)
+}
+
+func (b *Batch) SetLEDs(leds LEDs, powerColor byte, powerIntensity byte) error {
+	return b.add(opcLEDs, byte(leds), powerColor, powerIntensity)
+}
+
+func (b *Batch) SchedulingLEDs(days WeekdayLEDs, icons ScheduleLEDs) error {
+	return b.add(opcSchedulingLEDs, byte(days), byte(icons))
+}
+
+func (b *Batch) DigitLEDsRaw(digit [DigitLEDs]Segments) error {
+	return b.add(opcDigitLEDsRaw, digit)
+}
+
+func (b *Batch) DigitLEDsASCII(text string) error {
+	digit, err := encodeDigitASCII(text)
+	if nil != err {
+		return err
+	}
+	return b.add(opcDigitLEDsASCII, digit)
+}
+
+func (b *Batch) DefineSong(slot int, song Song) error {
+	data, err := encodeSong(slot, song)
+	if nil != err {
+		return err
+	}
+	return b.add(opcSong, data)
+}
+
+func (b *Batch) PlaySong(slot int) error {
+	if err := validateSongSlot(slot); nil != err {
+		return err
+	}
+	return b.add(opcPlay, byte(slot))
+}
+
+func (b *Batch) PressButtons(buttons ButtonState) error {
+	return b.add(opcButtons, byte(buttons))
+}
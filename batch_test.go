@@ -0,0 +1,99 @@
+package oibot
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	b := o.NewBatch()
+	if err := b.Drive(100, StraightDriveRadiusMM); nil != err {
+		t.Fatal(err)
+	}
+	if err := b.SetLEDs(LEDDock, 0, 255); nil != err {
+		t.Fatal(err)
+	}
+	if err := b.Drive(MaxDriveVelocityMMPS+1, StraightDriveRadiusMM); nil == err {
+		t.Fatal("Drive() accepted an invalid velocity")
+	}
+	want := []byte{byte(opcDrive), 0, 100, 0x7f, 0xff, byte(opcLEDs), byte(LEDDock), 0, 255}
+	if 2 != b.Len() || !bytes.Equal(want, b.Bytes()) {
+		t.Fatalf("Bytes() = %v; want %v", b.Bytes(), want)
+	}
+	// driving is illegal in Passive mode, so none of the batch is sent
+	var me *ModeError
+	if err := b.Send(); !errors.As(err, &me) || OIMPassive != me.Mode {
+		t.Fatalf("Send() in Passive = %v; want a ModeError", err)
+	}
+	if 2 != b.Len() || OIMPassive != sim.Mode() {
+		t.Fatalf("a refused batch left %d commands and the robot in %s", b.Len(), sim.Mode())
+	}
+	b.Reset()
+	b.Safe()
+	b.Drive(100, StraightDriveRadiusMM)
+	if err := b.Send(); nil != err {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if 0 != b.Len() || OIMSafe != sim.Mode() || 100 != sim.Sensors().VelocityLeftMMPS {
+		t.Fatalf("robot in %s at %d mm/s", sim.Mode(), sim.Sensors().VelocityLeftMMPS)
+	}
+	if mode, ok := o.TrackedMode(); !ok || OIMSafe != mode {
+		t.Fatalf("TrackedMode() = %s, %t after the batch", mode, ok)
+	}
+}
+
+// writeTimes records when each write to the wrapped transport was made.
+type writeTimes struct {
+	Transport
+	mu   sync.Mutex
+	when []time.Time
+}
+
+func (w *writeTimes) Write(buf []byte) (int, error) {
+	w.mu.Lock()
+	w.when = append(w.when, time.Now())
+	w.mu.Unlock()
+	return w.Transport.Write(buf)
+}
+
+func (w *writeTimes) since(n int) []time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]time.Time(nil), w.when[n:]...)
+}
+
+func TestBatchModeGuard(t *testing.T) {
+	rec := &writeTimes{}
+	o, sim := newSimBot(t, func(port Transport) Transport {
+		rec.Transport = port
+		return rec
+	})
+	b := o.NewBatch()
+	b.Safe()
+	b.Drive(100, StraightDriveRadiusMM)
+	b.SetLEDs(LEDDock, 0, 255)
+	b.Full()
+	if part := b.parts(); 2 != len(part) || 1 != len(part[0]) || 3 != len(part[1]) {
+		t.Fatalf("parts() = %v; want the Safe command, then the rest", part)
+	}
+	before := len(rec.since(0))
+	if err := b.Send(); nil != err {
+		t.Fatal(err)
+	}
+	when := rec.since(before)
+	if 2 != len(when) {
+		t.Fatalf("batch sent in %d writes; want 2", len(when))
+	}
+	if gap := when[1].Sub(when[0]); gap < DefaultPacing.ModeGuard {
+		t.Fatalf("%s between the mode change and the rest; want %s", gap, DefaultPacing.ModeGuard)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if OIMFull != sim.Mode() || 100 != sim.Sensors().VelocityLeftMMPS {
+		t.Fatalf("robot in %s at %d mm/s", sim.Mode(), sim.Sensors().VelocityLeftMMPS)
+	}
+}
@@ -0,0 +1,171 @@
+package oibot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatterySmoothing  float64       = 0.2
+	DefaultBatteryHysteresis float64       = 2 // percent
+	DefaultBatteryPeriod     time.Duration = time.Second
+)
+
+// BatteryReport is one BatteryMonitor sample with the quantities derived from
+// it. Current and power are exponentially smoothed; positive current charges
+// the battery and positive power is drawn from it.
+type BatteryReport struct {
+	Time         time.Time
+	Status       BatteryStatus
+	Percent      float64
+	CurrentmA    float64
+	PowermW      float64
+	TimeToEmpty  time.Duration // zero unless discharging
+	TimeToFull   time.Duration // zero unless charging
+	CapacityFade float64       // fraction of the first full charge since lost
+}
+
+type batteryAlarm struct {
+	percent float64
+	fn      func(BatteryReport)
+	armed   bool
+}
+
+// BatteryMonitor derives state of charge, power draw and runtime estimates
+// from a series of BatteryStatus samples, and raises callbacks when the charge
+// falls below configured thresholds.
+type BatteryMonitor struct {
+	Period     time.Duration
+	Smoothing  float64 // weight of each new sample, 0 to 1
+	Hysteresis float64 // percent above a threshold the charge must recover to rearm it
+
+	o        *OIBot
+	mu       sync.Mutex
+	last     *BatteryReport
+	alarm    []*batteryAlarm
+	charging bool
+	peak     float64 // highest charge seen during the current charge
+	firstmAh float64 // charge at the end of the first full charge seen
+	lastmAh  float64 // charge at the end of the latest full charge seen
+}
+
+func (o *OIBot) NewBatteryMonitor() *BatteryMonitor {
+	return &BatteryMonitor{
+		Period:     DefaultBatteryPeriod,
+		Smoothing:  DefaultBatterySmoothing,
+		Hysteresis: DefaultBatteryHysteresis,
+		o:          o,
+	}
+}
+
+// OnLow calls fn, once, each time the charge falls to or below percent.
+func (m *BatteryMonitor) OnLow(percent float64, fn func(BatteryReport)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alarm = append(m.alarm, &batteryAlarm{percent: percent, fn: fn, armed: true})
+}
+
+// Report returns the most recent sample, if any.
+func (m *BatteryMonitor) Report() (BatteryReport, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if nil == m.last {
+		return BatteryReport{}, false
+	}
+	return *m.last, true
+}
+
+func (m *BatteryMonitor) Run() error {
+	return m.RunContext(context.Background())
+}
+
+// RunContext samples the battery every Period, or DefaultBatteryPeriod if
+// Period is not positive, until ctx is done. Samples that time out or find the
+// link down are skipped.
+func (m *BatteryMonitor) RunContext(ctx context.Context) error {
+	period := m.Period
+	if period <= 0 {
+		period = DefaultBatteryPeriod
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		status, err := m.o.BatteryContext(ctx)
+		if nil != err && !transient(err) {
+			return err
+		}
+		if nil != status {
+			m.Sample(status, time.Now())
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample folds a BatteryStatus taken at time t into the monitor and returns the
+// resulting report. It may be fed from any source, such as a sensor stream.
+func (m *BatteryMonitor) Sample(status *BatteryStatus, t time.Time) BatteryReport {
+	m.mu.Lock()
+	r := BatteryReport{Time: t, Status: *status}
+	charge, capacity := float64(status.BatteryChargemAh), float64(status.BatteryCapacitymAh)
+	if capacity > 0 {
+		r.Percent = 100 * charge / capacity
+	}
+	current := float64(status.CurrentmA)
+	power := -float64(status.VoltagemV) * current / 1000
+	if nil == m.last {
+		r.CurrentmA, r.PowermW = current, power
+	} else {
+		r.CurrentmA = m.last.CurrentmA + m.Smoothing*(current-m.last.CurrentmA)
+		r.PowermW = m.last.PowermW + m.Smoothing*(power-m.last.PowermW)
+	}
+	switch {
+	case r.CurrentmA < 0:
+		r.TimeToEmpty = hoursDuration(charge / -r.CurrentmA)
+	case r.CurrentmA > 0 && status.ChargingState.IsCharging():
+		r.TimeToFull = hoursDuration((capacity - charge) / r.CurrentmA)
+	}
+
+	// The charge reached at the end of each full charge tracks how much the
+	// battery can actually hold; compare it with the first one seen.
+	charging := status.ChargingState.IsCharging()
+	if charging && charge > m.peak {
+		m.peak = charge
+	}
+	if m.charging && (!charging || ChargingStateTrickle == status.ChargingState) && m.peak > 0 {
+		if 0 == m.firstmAh {
+			m.firstmAh = m.peak
+		}
+		m.lastmAh, m.peak = m.peak, 0
+	}
+	m.charging = charging && ChargingStateTrickle != status.ChargingState
+	if m.firstmAh > 0 {
+		r.CapacityFade = 1 - m.lastmAh/m.firstmAh
+	}
+	m.last = &r
+
+	var fire []func(BatteryReport)
+	for _, a := range m.alarm {
+		switch {
+		case a.armed && r.Percent <= a.percent:
+			a.armed = false
+			fire = append(fire, a.fn)
+		case !a.armed && r.Percent > a.percent+m.Hysteresis:
+			a.armed = true
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range fire {
+		fn(r)
+	}
+	return r
+}
+
+func hoursDuration(h float64) time.Duration {
+	return time.Duration(h * float64(time.Hour))
+}
@@ -0,0 +1,137 @@
+package oibot
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBatteryMonitorReport(t *testing.T) {
+	m := (&OIBot{}).NewBatteryMonitor()
+	now := time.Now()
+	for _, test := range []struct {
+		status              BatteryStatus
+		percent, currentmA  float64
+		powermW             float64
+		timeToEmpty, toFull time.Duration
+	}{
+		{
+			status:  BatteryStatus{VoltagemV: 15000, CurrentmA: -1000, BatteryChargemAh: 1000, BatteryCapacitymAh: 2000},
+			percent: 50, currentmA: -1000, powermW: 15000, timeToEmpty: time.Hour,
+		},
+		{
+			// current and power move a fifth of the way to each new sample
+			status:  BatteryStatus{VoltagemV: 15000, CurrentmA: -2000, BatteryChargemAh: 900, BatteryCapacitymAh: 2000},
+			percent: 45, currentmA: -1200, powermW: 18000, timeToEmpty: 45 * time.Minute,
+		},
+		{
+			status:  BatteryStatus{ChargingState: ChargingStateFull, VoltagemV: 15000, CurrentmA: 4800, BatteryChargemAh: 900, BatteryCapacitymAh: 2000},
+			percent: 45, currentmA: 0, powermW: 0,
+		},
+		{
+			status:  BatteryStatus{ChargingState: ChargingStateFull, VoltagemV: 15000, CurrentmA: 1100, BatteryChargemAh: 1000, BatteryCapacitymAh: 2000},
+			percent: 50, currentmA: 220, powermW: -3300, toFull: hoursDuration(1000.0 / 220),
+		},
+	} {
+		r := m.Sample(&test.status, now)
+		if math.Abs(test.percent-r.Percent) > 1e-9 || math.Abs(test.currentmA-r.CurrentmA) > 1e-9 ||
+			math.Abs(test.powermW-r.PowermW) > 1e-9 || test.timeToEmpty != r.TimeToEmpty || test.toFull != r.TimeToFull {
+			t.Errorf("Sample(%+v) = %+v", test.status, r)
+		}
+	}
+}
+
+func TestBatteryMonitorAlarm(t *testing.T) {
+	m := (&OIBot{}).NewBatteryMonitor()
+	fired := 0
+	m.OnLow(20, func(BatteryReport) { fired++ })
+	for i, test := range []struct {
+		percent float64
+		fired   int
+	}{
+		{50, 0},
+		{20, 1}, // at the threshold
+		{19, 1},
+		{21, 1},
+		{22, 1}, // not above the 2% hysteresis band
+		{23, 1}, // rearmed
+		{19, 2},
+		{10, 2},
+	} {
+		m.Sample(&BatteryStatus{BatteryChargemAh: uint16(test.percent * 10), BatteryCapacitymAh: 1000}, time.Now())
+		if test.fired != fired {
+			t.Fatalf("sample %d at %g%%: alarm fired %d times; want %d", i, test.percent, fired, test.fired)
+		}
+	}
+}
+
+func TestBatteryMonitorFade(t *testing.T) {
+	m := (&OIBot{}).NewBatteryMonitor()
+	for i, test := range []struct {
+		state  ChargingState
+		charge uint16
+		fade   float64
+	}{
+		{ChargingStateNotCharging, 1000, 0},
+		{ChargingStateFull, 1500, 0},
+		{ChargingStateFull, 2000, 0},
+		{ChargingStateTrickle, 2000, 0}, // first full charge ends at 2000
+		{ChargingStateNotCharging, 1200, 0},
+		{ChargingStateFull, 1600, 0},
+		{ChargingStateFull, 1800, 0},
+		{ChargingStateNotCharging, 1800, 0.1}, // unplugged at 1800
+		{ChargingStateFull, 1900, 0.1},
+		{ChargingStateTrickle, 1900, 0.05},
+	} {
+		r := m.Sample(&BatteryStatus{ChargingState: test.state, BatteryChargemAh: test.charge, BatteryCapacitymAh: 2000}, time.Now())
+		if math.Abs(test.fade-r.CapacityFade) > 1e-9 {
+			t.Fatalf("sample %d: fade %g; want %g", i, r.CapacityFade, test.fade)
+		}
+	}
+}
+
+func TestBatteryMonitorPeriod(t *testing.T) {
+	o, _ := newSimBot(t, nil)
+	m := o.NewBatteryMonitor()
+	m.Period = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.RunContext(ctx); context.DeadlineExceeded != err {
+		t.Fatalf("RunContext() = %v; want context.DeadlineExceeded", err)
+	}
+	if r, ok := m.Report(); !ok || 0 == r.Status.BatteryCapacitymAh {
+		t.Fatalf("Report() = %+v, %t", r, ok)
+	}
+}
+
+func TestBatteryMonitorOutage(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	r := o.NewReconnector(ReconnectConfig{
+		Dial:       func() (Transport, error) { return sim.Transport(), nil },
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	m := o.NewBatteryMonitor()
+	m.Period = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.RunContext(ctx) }()
+	sim.Unplug()
+	expectState(t, ch, Disconnected)
+	expectState(t, ch, Reconnected)
+	select {
+	case err := <-done:
+		t.Fatalf("RunContext() = %v during the outage", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; context.Canceled != err {
+		t.Fatalf("RunContext() = %v; want context.Canceled", err)
+	}
+}
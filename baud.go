@@ -0,0 +1,171 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// BaudChangeDelay is how long the robot needs after a Baud command before
+	// it accepts data at the new rate.
+	BaudChangeDelay time.Duration = 100 * time.Millisecond
+	// BaudProbeTimeout bounds the wait for a reply at each rate tried.
+	BaudProbeTimeout time.Duration = 100 * time.Millisecond
+	// BRCBaudRateBPS is the rate the robot switches to when its Baud Rate
+	// Change pin is pulsed after power-on.
+	BRCBaudRateBPS int = 19200
+)
+
+// probeRates lists the rates to look for the robot at: the current rate, the
+// robot's power-on default, the BRC pin rate, then the rest fastest first.
+func probeRates(current int) []int {
+	rates := []int{current}
+	for _, rate := range []int{DefaultBaudRateBPS, BRCBaudRateBPS} {
+		if rate != current {
+			rates = append(rates, rate)
+		}
+	}
+	var rest []int
+	for rate := range codeForBaudRate {
+		if rate != current && rate != DefaultBaudRateBPS && rate != BRCBaudRateBPS {
+			rest = append(rest, rate)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(rest)))
+	return append(rates, rest...)
+}
+
+func (o *OIBot) NegotiateBaud(baud int) error {
+	return o.NegotiateBaudContext(context.Background(), baud)
+}
+
+// NegotiateBaudContext finds the rate the robot is listening at by trying each
+// supported rate in turn, then switches the robot and the host port to baud
+// together and confirms that the robot answers at the new rate. A robot found
+// in Off mode, as it is after a reset, is started in Passive mode. Each rate
+// is given BaudProbeTimeout to answer, which needs a transport that honors
+// read deadlines or a port opened with a read timeout. Probing is impossible
+// while a sensor stream is active. If the robot is not found, the host port
+// is left at the rate it started at.
+func (o *OIBot) NegotiateBaudContext(ctx context.Context, baud int) error {
+	if _, ok := codeForBaudRate[baud]; !ok {
+		return fmt.Errorf("%w: will not change to %d", ErrInvalidBaud, baud)
+	}
+	var mode OpenInterfaceMode
+	err := o.queue.do(ctx, func(ctx context.Context) error {
+		if nil != o.activeStream() {
+			return ErrStreamActive
+		}
+		var err error
+		mode, err = o.negotiate(ctx, baud)
+		return err
+	})
+	if nil != err {
+		return err
+	}
+	o.observeMode(mode)
+	return nil
+}
+
+// negotiate does the work of NegotiateBaudContext from within a job, returning
+// the mode the robot answered with at baud.
+func (o *OIBot) negotiate(ctx context.Context, baud int) (OpenInterfaceMode, error) {
+	// a Start sent while probing at the wrong rate never reaches the robot,
+	// so put back the mode we knew and let the caller compare the robot's
+	// answer against it.
+	defer o.setTrackedMode(o.TrackedMode())
+	original := o.BaudRate()
+	found, mode, err := o.probeBaud(ctx)
+	if nil != err {
+		o.setHostBaud(original)
+		return OIMOff, err
+	}
+	if found == baud {
+		return mode, nil
+	}
+	if err := o.changeBaud(ctx, baud); nil != err {
+		return OIMOff, err
+	}
+	if mode, err = o.probeMode(ctx); nil != err {
+		// go back to where the robot was last heard from
+		o.setHostBaud(found)
+		return OIMOff, fmt.Errorf("%w: no reply at %d baud after switching from %d: %s", ErrBaudNegotiation, baud, found, err)
+	}
+	return mode, nil
+}
+
+// probeBaud returns the first rate at which the robot answers a mode query,
+// and the mode it answered with, leaving the host port at that rate. Any
+// failure other than a missing or garbled reply ends the search.
+func (o *OIBot) probeBaud(ctx context.Context) (int, OpenInterfaceMode, error) {
+	rates := probeRates(o.BaudRate())
+	for _, rate := range rates {
+		if err := o.setHostBaud(rate); nil != err {
+			return 0, OIMOff, err
+		}
+		mode, err := o.probeMode(ctx)
+		if noReply(err) || errors.Is(err, ErrInvalidMode) {
+			// the robot ignores queries until started
+			if _, err = o.write(ctx, opcStart, nil); nil == err {
+				mode, err = o.probeMode(ctx)
+			}
+		}
+		switch {
+		case nil == err:
+			o.setTrackedMode(mode, true)
+			return rate, mode, nil
+		case !noReply(err):
+			return 0, OIMOff, err
+		}
+	}
+	return 0, OIMOff, fmt.Errorf("%w: no reply at any of %v baud", ErrBaudNegotiation, rates)
+}
+
+// probeMode reads the OI mode twice in one Query List, which a robot at
+// another rate is unlikely to answer consistently by accident.
+func (o *OIBot) probeMode(ctx context.Context) (OpenInterfaceMode, error) {
+	probe, cancel := context.WithTimeout(ctx, BaudProbeTimeout)
+	defer cancel()
+	packet := []*SensorPacket{PacketOpenInterfaceMode, PacketOpenInterfaceMode}
+	data, err := o.transact(probe, opcQueryList, packet, append([]byte{2}, o.sensorListID(packet...)...))
+	if nil != err {
+		if nil != probe.Err() && nil == ctx.Err() {
+			return OIMOff, ErrTimeout
+		}
+		return OIMOff, err
+	}
+	mode := OpenInterfaceMode(data[0][0])
+	if data[0][0] != data[1][0] || mode > OIMFull {
+		return OIMOff, fmt.Errorf("%w: inconsistent reply %v", ErrBaudNegotiation, data)
+	}
+	return mode, nil
+}
+
+// noReply reports whether err means only that the robot did not answer, or
+// answered with bytes that could not have been meant as a reply.
+func noReply(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrBaudNegotiation)
+}
+
+// changeBaud moves the robot, then the host port, to baud. Once the command is
+// out the host must follow regardless of ctx, or the two would be left apart.
+func (o *OIBot) changeBaud(ctx context.Context, baud int) error {
+	if _, err := o.write(ctx, opcBaud, []byte{byte(codeForBaudRate[baud])}); nil != err {
+		return err
+	}
+	time.Sleep(BaudChangeDelay)
+	return o.setHostBaud(baud)
+}
+
+func (o *OIBot) setHostBaud(baud int) error {
+	if err := o.transport().SetBaud(baud); nil != err {
+		return fmt.Errorf("failed to set host baud rate: %d: %w", baud, err)
+	}
+	o.mu.Lock()
+	o.baud = baud
+	o.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,82 @@
+package oibot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegotiateBaud(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	sim.SetBaud(BRCBaudRateBPS)
+	if _, err := o.Mode(); nil == err {
+		t.Fatal("Mode() answered across mismatched rates")
+	}
+	if err := o.NegotiateBaud(57600); nil != err {
+		t.Fatal(err)
+	}
+	if mode, err := o.Mode(); nil != err || OIMPassive != mode || 57600 != o.BaudRate() {
+		t.Fatalf("after NegotiateBaud: Mode() = %s, %v at %d baud", mode, err, o.BaudRate())
+	}
+	// a reset puts the robot back at its default rate, in Off mode
+	if err := o.Reset(); nil != err {
+		t.Fatal(err)
+	}
+	if err := o.NegotiateBaud(57600); nil != err {
+		t.Fatal(err)
+	}
+	if mode, err := o.Mode(); nil != err || OIMPassive != mode {
+		t.Fatalf("after a reset: Mode() = %s, %v", mode, err)
+	}
+	if err := o.NegotiateBaud(1234); !errors.Is(err, ErrInvalidBaud) {
+		t.Fatalf("NegotiateBaud(1234) = %v", err)
+	}
+	if r := probeRates(57600); 12 != len(r) || 57600 != r[0] || DefaultBaudRateBPS != r[1] || BRCBaudRateBPS != r[2] {
+		t.Fatalf("probeRates(57600) = %v", r)
+	}
+}
+
+func TestNegotiateBaudRecovery(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	s, err := o.Stream(PacketBumpsWheeldrops)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := o.NegotiateBaud(57600); ErrStreamActive != err {
+		t.Fatalf("NegotiateBaud() while streaming = %v", err)
+	}
+	s.Stop()
+	if OIMSafe != sim.Mode() || DefaultBaudRateBPS != o.BaudRate() {
+		t.Fatalf("a refused negotiation left the robot in %s at %d baud", sim.Mode(), o.BaudRate())
+	}
+	sim.SetBaud(1234) // a rate no probe will find
+	if err := o.NegotiateBaud(57600); !errors.Is(err, ErrBaudNegotiation) {
+		t.Fatalf("NegotiateBaud() with no robot = %v", err)
+	}
+	if DefaultBaudRateBPS != o.BaudRate() {
+		t.Fatalf("a failed negotiation left the host at %d baud", o.BaudRate())
+	}
+	sim.SetBaud(DefaultBaudRateBPS)
+	if mode, err := o.Mode(); nil != err || OIMSafe != mode {
+		t.Fatalf("Mode() = %s, %v; want %s", mode, err, OIMSafe)
+	}
+}
+
+func TestNegotiateBaudModeChange(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	var change []ModeChange
+	o.OnModeChange(func(c ModeChange) { change = append(change, c) })
+	// Start is sent, and lost, at each rate tried before the robot's
+	sim.SetBaud(BRCBaudRateBPS)
+	if err := o.NegotiateBaud(57600); nil != err {
+		t.Fatal(err)
+	}
+	if mode, _ := o.TrackedMode(); OIMSafe != mode || 0 != len(change) {
+		t.Fatalf("tracked mode %s after NegotiateBaud, with changes %+v", mode, change)
+	}
+}
@@ -0,0 +1,63 @@
+package oibot
+
+import (
+	"context"
+	"strings"
+)
+
+type ButtonState byte
+
+const (
+	ButtonClean    ButtonState = 1 << 0
+	ButtonSpot     ButtonState = 1 << 1
+	ButtonDock     ButtonState = 1 << 2
+	ButtonMinute   ButtonState = 1 << 3
+	ButtonHour     ButtonState = 1 << 4
+	ButtonDay      ButtonState = 1 << 5
+	ButtonSchedule ButtonState = 1 << 6
+	ButtonClock    ButtonState = 1 << 7
+)
+
+var buttonStr = [...]string{"CLEAN", "SPOT", "DOCK", "MINUTE", "HOUR", "DAY", "SCHEDULE", "CLOCK"}
+
+func (b ButtonState) Has(button ButtonState) bool {
+	return 0 != button && button == b&button
+}
+
+func (b ButtonState) String() string {
+	var name []string
+	for i, s := range buttonStr {
+		if 0 != b&(1<<uint(i)) {
+			name = append(name, s)
+		}
+	}
+	if 0 == len(name) {
+		return "NONE"
+	}
+	return strings.Join(name, "|")
+}
+
+// =============================================================================
+
+// PressButtons pushes the given buttons as if by hand. They are released
+// automatically after about 1/6 of a second.
+func (o *OIBot) PressButtons(buttons ButtonState) error {
+	return o.PressButtonsContext(context.Background(), buttons)
+}
+
+func (o *OIBot) PressButtonsContext(ctx context.Context, buttons ButtonState) error {
+	return o.command(ctx, opcButtons, byte(buttons))
+}
+
+// Buttons returns the buttons currently held down on the robot.
+func (o *OIBot) Buttons() (ButtonState, error) {
+	return o.ButtonsContext(context.Background())
+}
+
+func (o *OIBot) ButtonsContext(ctx context.Context) (ButtonState, error) {
+	data, err := o.SensorContext(ctx, PacketButtons)
+	if nil != err {
+		return 0, err
+	}
+	return ButtonState(data[0]), nil
+}
@@ -1,6 +1,9 @@
 package oibot
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // =====================================================================================================================
 type OpCode byte
@@ -81,10 +84,20 @@ const (
 	DriveWheelSeparationMM int16 = 298
 )
 
+const (
+	WheelDiameterMM     float64 = 72.0
+	EncoderCountsPerRev float64 = 508.8
+)
+
 // =====================================================================================================================
 type SensorPacket struct {
-	id   byte
-	size byte
+	id     byte
+	size   byte
+	name   string
+	signed bool
+	min    int32
+	max    int32
+	units  string
 }
 
 // -------------------------- ---- ------ ------------------------------- ---------------- -------
@@ -144,64 +157,65 @@ type SensorPacket struct {
 //  Stasis                     58   1                    100 101     107        0 - 3
 // -------------------------- ---- ------ ------------------------------- ---------------- -------
 var (
-	spcBumpsWheeldrops       = &SensorPacket{id: 7, size: 1}
-	spcWall                  = &SensorPacket{id: 8, size: 1}
-	spcCliffLeft             = &SensorPacket{id: 9, size: 1}
-	spcCliffFrontLeft        = &SensorPacket{id: 10, size: 1}
-	spcCliffFrontRight       = &SensorPacket{id: 11, size: 1}
-	spcCliffRight            = &SensorPacket{id: 12, size: 1}
-	spcVirtualWall           = &SensorPacket{id: 13, size: 1}
-	spcOvercurrents          = &SensorPacket{id: 14, size: 1}
-	spcDirtDetect            = &SensorPacket{id: 15, size: 1}
-	spcUnused1               = &SensorPacket{id: 16, size: 1}
-	spcIROpCode              = &SensorPacket{id: 17, size: 1}
-	spcButtons               = &SensorPacket{id: 18, size: 1}
-	spcDistance              = &SensorPacket{id: 19, size: 2}
-	spcAngle                 = &SensorPacket{id: 20, size: 2}
-	spcChargingState         = &SensorPacket{id: 21, size: 1}
-	spcVoltage               = &SensorPacket{id: 22, size: 2}
-	spcCurrent               = &SensorPacket{id: 23, size: 2}
-	spcTemperature           = &SensorPacket{id: 24, size: 1}
-	spcBatteryCharge         = &SensorPacket{id: 25, size: 2}
-	spcBatteryCapacity       = &SensorPacket{id: 26, size: 2}
-	spcWallSignal            = &SensorPacket{id: 27, size: 2}
-	spcCliffLeftSignal       = &SensorPacket{id: 28, size: 2}
-	spcCliffFrontLeftSignal  = &SensorPacket{id: 29, size: 2}
-	spcCliffFrontRightSignal = &SensorPacket{id: 30, size: 2}
-	spcCliffRightSignal      = &SensorPacket{id: 31, size: 2}
-	spcUnused2               = &SensorPacket{id: 32, size: 1}
-	spcUnused3               = &SensorPacket{id: 33, size: 2}
-	spcChargerAvailable      = &SensorPacket{id: 34, size: 1}
-	spcOpenInterfaceMode     = &SensorPacket{id: 35, size: 1}
-	spcSongNumber            = &SensorPacket{id: 36, size: 1}
-	spcSongPlaying           = &SensorPacket{id: 37, size: 1}
-	spcOIStreamNumPackets    = &SensorPacket{id: 38, size: 1}
-	spcVelocity              = &SensorPacket{id: 39, size: 2}
-	spcRadius                = &SensorPacket{id: 40, size: 2}
-	spcVelocityRight         = &SensorPacket{id: 41, size: 2}
-	spcVelocityLeft          = &SensorPacket{id: 42, size: 2}
-	spcEncoderCountsLeft     = &SensorPacket{id: 43, size: 2}
-	spcEncoderCountsRight    = &SensorPacket{id: 44, size: 2}
-	spcLightBumper           = &SensorPacket{id: 45, size: 1}
-	spcLightBumpLeft         = &SensorPacket{id: 46, size: 2}
-	spcLightBumpFrontLeft    = &SensorPacket{id: 47, size: 2}
-	spcLightBumpCenterLeft   = &SensorPacket{id: 48, size: 2}
-	spcLightBumpCenterRight  = &SensorPacket{id: 49, size: 2}
-	spcLightBumpFrontRight   = &SensorPacket{id: 50, size: 2}
-	spcLightBumpRight        = &SensorPacket{id: 51, size: 2}
-	spcIROpCodeLeft          = &SensorPacket{id: 52, size: 1}
-	spcIROpCodeRight         = &SensorPacket{id: 53, size: 1}
-	spcLeftMotorCurrent      = &SensorPacket{id: 54, size: 2}
-	spcRightMotorCurrent     = &SensorPacket{id: 55, size: 2}
-	spcMainBrushCurrent      = &SensorPacket{id: 56, size: 2}
-	spcSideBrushCurrent      = &SensorPacket{id: 57, size: 2}
-	spcStasis                = &SensorPacket{id: 58, size: 1}
+	PacketBumpsWheeldrops       = &SensorPacket{id: 7, size: 1, name: "Bumps Wheeldrops", signed: false, min: 0, max: 15, units: ""}
+	PacketWall                  = &SensorPacket{id: 8, size: 1, name: "Wall", signed: false, min: 0, max: 1, units: ""}
+	PacketCliffLeft             = &SensorPacket{id: 9, size: 1, name: "Cliff Left", signed: false, min: 0, max: 1, units: ""}
+	PacketCliffFrontLeft        = &SensorPacket{id: 10, size: 1, name: "Cliff Front Left", signed: false, min: 0, max: 1, units: ""}
+	PacketCliffFrontRight       = &SensorPacket{id: 11, size: 1, name: "Cliff Front Right", signed: false, min: 0, max: 1, units: ""}
+	PacketCliffRight            = &SensorPacket{id: 12, size: 1, name: "Cliff Right", signed: false, min: 0, max: 1, units: ""}
+	PacketVirtualWall           = &SensorPacket{id: 13, size: 1, name: "Virtual Wall", signed: false, min: 0, max: 1, units: ""}
+	PacketOvercurrents          = &SensorPacket{id: 14, size: 1, name: "Overcurrents", signed: false, min: 0, max: 29, units: ""}
+	PacketDirtDetect            = &SensorPacket{id: 15, size: 1, name: "Dirt Detect", signed: false, min: 0, max: 255, units: ""}
+	PacketUnused1               = &SensorPacket{id: 16, size: 1, name: "Unused 1", signed: false, min: 0, max: 255, units: ""}
+	PacketIROpCode              = &SensorPacket{id: 17, size: 1, name: "IR OpCode", signed: false, min: 0, max: 255, units: ""}
+	PacketButtons               = &SensorPacket{id: 18, size: 1, name: "Buttons", signed: false, min: 0, max: 255, units: ""}
+	PacketDistance              = &SensorPacket{id: 19, size: 2, name: "Distance", signed: true, min: -32768, max: 32767, units: "mm"}
+	PacketAngle                 = &SensorPacket{id: 20, size: 2, name: "Angle", signed: true, min: -32768, max: 32767, units: "degrees"}
+	PacketChargingState         = &SensorPacket{id: 21, size: 1, name: "Charging State", signed: false, min: 0, max: 6, units: ""}
+	PacketVoltage               = &SensorPacket{id: 22, size: 2, name: "Voltage", signed: false, min: 0, max: 65535, units: "mV"}
+	PacketCurrent               = &SensorPacket{id: 23, size: 2, name: "Current", signed: true, min: -32768, max: 32767, units: "mA"}
+	PacketTemperature           = &SensorPacket{id: 24, size: 1, name: "Temperature", signed: true, min: -128, max: 127, units: "deg C"}
+	PacketBatteryCharge         = &SensorPacket{id: 25, size: 2, name: "Battery Charge", signed: false, min: 0, max: 65535, units: "mAh"}
+	PacketBatteryCapacity       = &SensorPacket{id: 26, size: 2, name: "Battery Capacity", signed: false, min: 0, max: 65535, units: "mAh"}
+	PacketWallSignal            = &SensorPacket{id: 27, size: 2, name: "Wall Signal", signed: false, min: 0, max: 1023, units: ""}
+	PacketCliffLeftSignal       = &SensorPacket{id: 28, size: 2, name: "Cliff Left Signal", signed: false, min: 0, max: 4095, units: ""}
+	PacketCliffFrontLeftSignal  = &SensorPacket{id: 29, size: 2, name: "Cliff Front Left Signal", signed: false, min: 0, max: 4095, units: ""}
+	PacketCliffFrontRightSignal = &SensorPacket{id: 30, size: 2, name: "Cliff Front Right Signal", signed: false, min: 0, max: 4095, units: ""}
+	PacketCliffRightSignal      = &SensorPacket{id: 31, size: 2, name: "Cliff Right Signal", signed: false, min: 0, max: 4095, units: ""}
+	PacketUnused2               = &SensorPacket{id: 32, size: 1, name: "Unused 2", signed: false, min: 0, max: 255, units: ""}
+	PacketUnused3               = &SensorPacket{id: 33, size: 2, name: "Unused 3", signed: false, min: 0, max: 65535, units: ""}
+	PacketChargerAvailable      = &SensorPacket{id: 34, size: 1, name: "Charger Available", signed: false, min: 0, max: 3, units: ""}
+	PacketOpenInterfaceMode     = &SensorPacket{id: 35, size: 1, name: "Open Interface Mode", signed: false, min: 0, max: 3, units: ""}
+	PacketSongNumber            = &SensorPacket{id: 36, size: 1, name: "Song Number", signed: false, min: 0, max: 4, units: ""}
+	PacketSongPlaying           = &SensorPacket{id: 37, size: 1, name: "Song Playing?", signed: false, min: 0, max: 1, units: ""}
+	PacketOIStreamNumPackets    = &SensorPacket{id: 38, size: 1, name: "Oi Stream Num Packets", signed: false, min: 0, max: 108, units: ""}
+	PacketVelocity              = &SensorPacket{id: 39, size: 2, name: "Velocity", signed: true, min: -500, max: 500, units: "mm/s"}
+	PacketRadius                = &SensorPacket{id: 40, size: 2, name: "Radius", signed: true, min: -32768, max: 32767, units: "mm"}
+	PacketVelocityRight         = &SensorPacket{id: 41, size: 2, name: "Velocity Right", signed: true, min: -500, max: 500, units: "mm/s"}
+	PacketVelocityLeft          = &SensorPacket{id: 42, size: 2, name: "Velocity Left", signed: true, min: -500, max: 500, units: "mm/s"}
+	PacketEncoderCountsLeft     = &SensorPacket{id: 43, size: 2, name: "Encoder Counts Left", signed: false, min: 0, max: 65535, units: ""}
+	PacketEncoderCountsRight    = &SensorPacket{id: 44, size: 2, name: "Encoder Counts Right", signed: false, min: 0, max: 65535, units: ""}
+	PacketLightBumper           = &SensorPacket{id: 45, size: 1, name: "Light Bumper", signed: false, min: 0, max: 127, units: ""}
+	PacketLightBumpLeft         = &SensorPacket{id: 46, size: 2, name: "Light Bump Left", signed: false, min: 0, max: 4095, units: ""}
+	PacketLightBumpFrontLeft    = &SensorPacket{id: 47, size: 2, name: "Light Bump Front Left", signed: false, min: 0, max: 4095, units: ""}
+	PacketLightBumpCenterLeft   = &SensorPacket{id: 48, size: 2, name: "Light Bump Center Left", signed: false, min: 0, max: 4095, units: ""}
+	PacketLightBumpCenterRight  = &SensorPacket{id: 49, size: 2, name: "Light Bump Center Right", signed: false, min: 0, max: 4095, units: ""}
+	PacketLightBumpFrontRight   = &SensorPacket{id: 50, size: 2, name: "Light Bump Front Right", signed: false, min: 0, max: 4095, units: ""}
+	PacketLightBumpRight        = &SensorPacket{id: 51, size: 2, name: "Light Bump Right", signed: false, min: 0, max: 4095, units: ""}
+	PacketIROpCodeLeft          = &SensorPacket{id: 52, size: 1, name: "IR OpCode Left", signed: false, min: 0, max: 255, units: ""}
+	PacketIROpCodeRight         = &SensorPacket{id: 53, size: 1, name: "IR OpCode Right", signed: false, min: 0, max: 255, units: ""}
+	PacketLeftMotorCurrent      = &SensorPacket{id: 54, size: 2, name: "Left Motor Current", signed: true, min: -32768, max: 32767, units: "mA"}
+	PacketRightMotorCurrent     = &SensorPacket{id: 55, size: 2, name: "Right Motor Current", signed: true, min: -32768, max: 32767, units: "mA"}
+	PacketMainBrushCurrent      = &SensorPacket{id: 56, size: 2, name: "Main Brush Current", signed: true, min: -32768, max: 32767, units: "mA"}
+	PacketSideBrushCurrent      = &SensorPacket{id: 57, size: 2, name: "Side Brush Current", signed: true, min: -32768, max: 32767, units: "mA"}
+	PacketStasis                = &SensorPacket{id: 58, size: 1, name: "Stasis", signed: false, min: 0, max: 3, units: ""}
 )
 
 // =====================================================================================================================
 type SensorGroup struct {
 	id     byte
 	size   byte
+	name   string
 	member []*SensorPacket
 }
 
@@ -222,17 +236,17 @@ type SensorGroup struct {
 //  Actuator                    107   9      54 - 58
 // --------------------------  ----- ------ ------------------------------- ---------------- -------
 var (
-	sgpStatus    = &SensorGroup{id: 0, size: 26, member: []*SensorPacket{spcBumpsWheeldrops, spcWall, spcCliffLeft, spcCliffFrontLeft, spcCliffFrontRight, spcCliffRight, spcVirtualWall, spcOvercurrents, spcDirtDetect, spcUnused1, spcIROpCode, spcButtons, spcDistance, spcAngle, spcChargingState, spcVoltage, spcCurrent, spcTemperature, spcBatteryCharge, spcBatteryCapacity}}
-	sgpObstacle  = &SensorGroup{id: 1, size: 10, member: []*SensorPacket{spcBumpsWheeldrops, spcWall, spcCliffLeft, spcCliffFrontLeft, spcCliffFrontRight, spcCliffRight, spcVirtualWall, spcOvercurrents, spcDirtDetect, spcUnused1}}
-	sgpDock      = &SensorGroup{id: 2, size: 6, member: []*SensorPacket{spcIROpCode, spcButtons, spcDistance, spcAngle}}
-	sgpBattery   = &SensorGroup{id: 3, size: 10, member: []*SensorPacket{spcChargingState, spcVoltage, spcCurrent, spcTemperature, spcBatteryCharge, spcBatteryCapacity}}
-	sgpSignal    = &SensorGroup{id: 4, size: 14, member: []*SensorPacket{spcWallSignal, spcCliffLeftSignal, spcCliffFrontLeftSignal, spcCliffFrontRightSignal, spcCliffRightSignal, spcUnused2, spcUnused3, spcChargerAvailable}}
-	sgpModeData  = &SensorGroup{id: 5, size: 12, member: []*SensorPacket{spcOpenInterfaceMode, spcSongNumber, spcSongPlaying, spcOIStreamNumPackets, spcVelocity, spcRadius, spcVelocityRight, spcVelocityLeft}}
-	sgpSensor    = &SensorGroup{id: 6, size: 52, member: []*SensorPacket{spcBumpsWheeldrops, spcWall, spcCliffLeft, spcCliffFrontLeft, spcCliffFrontRight, spcCliffRight, spcVirtualWall, spcOvercurrents, spcDirtDetect, spcUnused1, spcIROpCode, spcButtons, spcDistance, spcAngle, spcChargingState, spcVoltage, spcCurrent, spcTemperature, spcBatteryCharge, spcBatteryCapacity, spcWallSignal, spcCliffLeftSignal, spcCliffFrontLeftSignal, spcCliffFrontRightSignal, spcCliffRightSignal, spcUnused2, spcUnused3, spcChargerAvailable, spcOpenInterfaceMode, spcSongNumber, spcSongPlaying, spcOIStreamNumPackets, spcVelocity, spcRadius, spcVelocityRight, spcVelocityLeft}}
-	sgpAll       = &SensorGroup{id: 100, size: 80, member: []*SensorPacket{spcBumpsWheeldrops, spcWall, spcCliffLeft, spcCliffFrontLeft, spcCliffFrontRight, spcCliffRight, spcVirtualWall, spcOvercurrents, spcDirtDetect, spcUnused1, spcIROpCode, spcButtons, spcDistance, spcAngle, spcChargingState, spcVoltage, spcCurrent, spcTemperature, spcBatteryCharge, spcBatteryCapacity, spcWallSignal, spcCliffLeftSignal, spcCliffFrontLeftSignal, spcCliffFrontRightSignal, spcCliffRightSignal, spcUnused2, spcUnused3, spcChargerAvailable, spcOpenInterfaceMode, spcSongNumber, spcSongPlaying, spcOIStreamNumPackets, spcVelocity, spcRadius, spcVelocityRight, spcVelocityLeft, spcEncoderCountsLeft, spcEncoderCountsRight, spcLightBumper, spcLightBumpLeft, spcLightBumpFrontLeft, spcLightBumpCenterLeft, spcLightBumpCenterRight, spcLightBumpFrontRight, spcLightBumpRight, spcIROpCodeLeft, spcIROpCodeRight, spcLeftMotorCurrent, spcRightMotorCurrent, spcMainBrushCurrent, spcSideBrushCurrent, spcStasis}}
-	sgpDrive     = &SensorGroup{id: 101, size: 28, member: []*SensorPacket{spcEncoderCountsLeft, spcEncoderCountsRight, spcLightBumper, spcLightBumpLeft, spcLightBumpFrontLeft, spcLightBumpCenterLeft, spcLightBumpCenterRight, spcLightBumpFrontRight, spcLightBumpRight, spcIROpCodeLeft, spcIROpCodeRight, spcLeftMotorCurrent, spcRightMotorCurrent, spcMainBrushCurrent, spcSideBrushCurrent, spcStasis}}
-	sgpProximity = &SensorGroup{id: 106, size: 12, member: []*SensorPacket{spcLightBumpLeft, spcLightBumpFrontLeft, spcLightBumpCenterLeft, spcLightBumpCenterRight, spcLightBumpFrontRight, spcLightBumpRight}}
-	sgpActuator  = &SensorGroup{id: 107, size: 9, member: []*SensorPacket{spcLeftMotorCurrent, spcRightMotorCurrent, spcMainBrushCurrent, spcSideBrushCurrent, spcStasis}}
+	GroupStatus    = &SensorGroup{id: 0, size: 26, name: "Status", member: []*SensorPacket{PacketBumpsWheeldrops, PacketWall, PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight, PacketVirtualWall, PacketOvercurrents, PacketDirtDetect, PacketUnused1, PacketIROpCode, PacketButtons, PacketDistance, PacketAngle, PacketChargingState, PacketVoltage, PacketCurrent, PacketTemperature, PacketBatteryCharge, PacketBatteryCapacity}}
+	GroupObstacle  = &SensorGroup{id: 1, size: 10, name: "Obstacle", member: []*SensorPacket{PacketBumpsWheeldrops, PacketWall, PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight, PacketVirtualWall, PacketOvercurrents, PacketDirtDetect, PacketUnused1}}
+	GroupDock      = &SensorGroup{id: 2, size: 6, name: "Dock", member: []*SensorPacket{PacketIROpCode, PacketButtons, PacketDistance, PacketAngle}}
+	GroupBattery   = &SensorGroup{id: 3, size: 10, name: "Battery", member: []*SensorPacket{PacketChargingState, PacketVoltage, PacketCurrent, PacketTemperature, PacketBatteryCharge, PacketBatteryCapacity}}
+	GroupSignal    = &SensorGroup{id: 4, size: 14, name: "Signal", member: []*SensorPacket{PacketWallSignal, PacketCliffLeftSignal, PacketCliffFrontLeftSignal, PacketCliffFrontRightSignal, PacketCliffRightSignal, PacketUnused2, PacketUnused3, PacketChargerAvailable}}
+	GroupModeData  = &SensorGroup{id: 5, size: 12, name: "ModeData", member: []*SensorPacket{PacketOpenInterfaceMode, PacketSongNumber, PacketSongPlaying, PacketOIStreamNumPackets, PacketVelocity, PacketRadius, PacketVelocityRight, PacketVelocityLeft}}
+	GroupSensor    = &SensorGroup{id: 6, size: 52, name: "Sensor", member: []*SensorPacket{PacketBumpsWheeldrops, PacketWall, PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight, PacketVirtualWall, PacketOvercurrents, PacketDirtDetect, PacketUnused1, PacketIROpCode, PacketButtons, PacketDistance, PacketAngle, PacketChargingState, PacketVoltage, PacketCurrent, PacketTemperature, PacketBatteryCharge, PacketBatteryCapacity, PacketWallSignal, PacketCliffLeftSignal, PacketCliffFrontLeftSignal, PacketCliffFrontRightSignal, PacketCliffRightSignal, PacketUnused2, PacketUnused3, PacketChargerAvailable, PacketOpenInterfaceMode, PacketSongNumber, PacketSongPlaying, PacketOIStreamNumPackets, PacketVelocity, PacketRadius, PacketVelocityRight, PacketVelocityLeft}}
+	GroupAll       = &SensorGroup{id: 100, size: 80, name: "All", member: []*SensorPacket{PacketBumpsWheeldrops, PacketWall, PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight, PacketVirtualWall, PacketOvercurrents, PacketDirtDetect, PacketUnused1, PacketIROpCode, PacketButtons, PacketDistance, PacketAngle, PacketChargingState, PacketVoltage, PacketCurrent, PacketTemperature, PacketBatteryCharge, PacketBatteryCapacity, PacketWallSignal, PacketCliffLeftSignal, PacketCliffFrontLeftSignal, PacketCliffFrontRightSignal, PacketCliffRightSignal, PacketUnused2, PacketUnused3, PacketChargerAvailable, PacketOpenInterfaceMode, PacketSongNumber, PacketSongPlaying, PacketOIStreamNumPackets, PacketVelocity, PacketRadius, PacketVelocityRight, PacketVelocityLeft, PacketEncoderCountsLeft, PacketEncoderCountsRight, PacketLightBumper, PacketLightBumpLeft, PacketLightBumpFrontLeft, PacketLightBumpCenterLeft, PacketLightBumpCenterRight, PacketLightBumpFrontRight, PacketLightBumpRight, PacketIROpCodeLeft, PacketIROpCodeRight, PacketLeftMotorCurrent, PacketRightMotorCurrent, PacketMainBrushCurrent, PacketSideBrushCurrent, PacketStasis}}
+	GroupDrive     = &SensorGroup{id: 101, size: 28, name: "Drive", member: []*SensorPacket{PacketEncoderCountsLeft, PacketEncoderCountsRight, PacketLightBumper, PacketLightBumpLeft, PacketLightBumpFrontLeft, PacketLightBumpCenterLeft, PacketLightBumpCenterRight, PacketLightBumpFrontRight, PacketLightBumpRight, PacketIROpCodeLeft, PacketIROpCodeRight, PacketLeftMotorCurrent, PacketRightMotorCurrent, PacketMainBrushCurrent, PacketSideBrushCurrent, PacketStasis}}
+	GroupProximity = &SensorGroup{id: 106, size: 12, name: "Proximity", member: []*SensorPacket{PacketLightBumpLeft, PacketLightBumpFrontLeft, PacketLightBumpCenterLeft, PacketLightBumpCenterRight, PacketLightBumpFrontRight, PacketLightBumpRight}}
+	GroupActuator  = &SensorGroup{id: 107, size: 9, name: "Actuator", member: []*SensorPacket{PacketLeftMotorCurrent, PacketRightMotorCurrent, PacketMainBrushCurrent, PacketSideBrushCurrent, PacketStasis}}
 )
 
 // =====================================================================================================================
@@ -279,17 +293,63 @@ const (
 )
 
 // =====================================================================================================================
-type ChargingStateCode byte
+type ChargingState byte
+
+// ChargingStateCode is the former name of ChargingState.
+type ChargingStateCode = ChargingState
 
 const (
-	cstNotCharging            ChargingStateCode = 0
-	cstReconditioningCharging ChargingStateCode = 1
-	cstFullCharging           ChargingStateCode = 2
-	cstTrickleCharging        ChargingStateCode = 3
-	cstWaiting                ChargingStateCode = 4
-	cstChargingFaultCondition ChargingStateCode = 5
+	ChargingStateNotCharging    ChargingState = 0
+	ChargingStateReconditioning ChargingState = 1
+	ChargingStateFull           ChargingState = 2
+	ChargingStateTrickle        ChargingState = 3
+	ChargingStateWaiting        ChargingState = 4
+	ChargingStateFault          ChargingState = 5
 )
 
+var (
+	chargingStateStr = [...]string{"NOT CHARGING", "RECONDITIONING", "FULL CHARGING", "TRICKLE CHARGING", "WAITING", "FAULT"}
+)
+
+func (c ChargingState) String() string {
+	if int(c) < len(chargingStateStr) {
+		return chargingStateStr[c]
+	}
+	return fmt.Sprintf("CHARGING STATE(%d)", byte(c))
+}
+
+// IsCharging reports whether current is flowing into the battery.
+func (c ChargingState) IsCharging() bool {
+	return ChargingStateReconditioning == c || ChargingStateFull == c || ChargingStateTrickle == c
+}
+
+func (c ChargingState) IsFault() bool {
+	return ChargingStateFault == c
+}
+
+// ChargerAvailable reports which charging sources are connected.
+type ChargerAvailable byte
+
+const (
+	ChargerInternal ChargerAvailable = 1 << 0
+	ChargerHomeBase ChargerAvailable = 1 << 1
+)
+
+func (c ChargerAvailable) Internal() bool { return 0 != c&ChargerInternal }
+func (c ChargerAvailable) HomeBase() bool { return 0 != c&ChargerHomeBase }
+
+func (c ChargerAvailable) String() string {
+	switch c & (ChargerInternal | ChargerHomeBase) {
+	case ChargerInternal:
+		return "INTERNAL"
+	case ChargerHomeBase:
+		return "HOME BASE"
+	case ChargerInternal | ChargerHomeBase:
+		return "INTERNAL|HOME BASE"
+	}
+	return "NONE"
+}
+
 // =====================================================================================================================
 type OpenInterfaceMode byte
 
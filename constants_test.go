@@ -0,0 +1,41 @@
+package oibot
+
+import "testing"
+
+func TestChargingState(t *testing.T) {
+	for _, test := range []struct {
+		state    ChargingState
+		str      string
+		charging bool
+		fault    bool
+	}{
+		{ChargingStateNotCharging, "NOT CHARGING", false, false},
+		{ChargingStateReconditioning, "RECONDITIONING", true, false},
+		{ChargingStateFull, "FULL CHARGING", true, false},
+		{ChargingStateTrickle, "TRICKLE CHARGING", true, false},
+		{ChargingStateWaiting, "WAITING", false, false},
+		{ChargingStateFault, "FAULT", false, true},
+		{ChargingState(9), "CHARGING STATE(9)", false, false},
+	} {
+		if test.str != test.state.String() || test.charging != test.state.IsCharging() || test.fault != test.state.IsFault() {
+			t.Errorf("%d: %q, charging %t, fault %t", byte(test.state), test.state, test.state.IsCharging(), test.state.IsFault())
+		}
+	}
+}
+
+func TestChargerAvailable(t *testing.T) {
+	for _, test := range []struct {
+		available          ChargerAvailable
+		str                string
+		internal, homeBase bool
+	}{
+		{0, "NONE", false, false},
+		{ChargerInternal, "INTERNAL", true, false},
+		{ChargerHomeBase, "HOME BASE", false, true},
+		{ChargerInternal | ChargerHomeBase, "INTERNAL|HOME BASE", true, true},
+	} {
+		if test.str != test.available.String() || test.internal != test.available.Internal() || test.homeBase != test.available.HomeBase() {
+			t.Errorf("%d: %q, internal %t, home base %t", byte(test.available), test.available, test.available.Internal(), test.available.HomeBase())
+		}
+	}
+}
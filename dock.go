@@ -0,0 +1,223 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Infrared characters sent by the Home Base, as seen on the IR OpCode packets
+// (17, 52 and 53). The low nibble is a bitmask of the beams received.
+const (
+	IRDockReserved   byte = 160
+	IRDockForceField byte = 161
+	IRDockGreenBuoy  byte = 164
+	IRDockRedBuoy    byte = 168
+)
+
+func IsDockIR(code byte) bool {
+	return IRDockReserved == code&0xF0 && code != IRDockReserved
+}
+
+type DockOutcome int
+
+const (
+	DockSucceeded DockOutcome = iota
+	DockFailed
+	DockCanceled
+)
+
+var dockOutcomeStr = [...]string{"DOCKED", "FAILED", "CANCELED"}
+
+func (d DockOutcome) String() string {
+	if int(d) < len(dockOutcomeStr) {
+		return dockOutcomeStr[d]
+	}
+	return fmt.Sprintf("DOCK OUTCOME(%d)", int(d))
+}
+
+type DockResult struct {
+	Outcome  DockOutcome
+	Attempts int
+	Duration time.Duration
+	SawDock  bool // whether the Home Base's IR beams were ever seen
+	Err      error
+}
+
+type DockConfig struct {
+	Attempts       int
+	AttemptTimeout time.Duration
+	Period         time.Duration
+	// ConfirmSamples is how many consecutive samples must report the Home
+	// Base as a charging source before the robot is considered docked.
+	ConfirmSamples int
+	// BackoffMM is how far to reverse away from the dock between attempts.
+	BackoffMM float64
+}
+
+var DefaultDockConfig = DockConfig{
+	Attempts:       3,
+	AttemptTimeout: 2 * time.Minute,
+	Period:         250 * time.Millisecond,
+	ConfirmSamples: 3,
+	BackoffMM:      250,
+}
+
+var dockPacket = []*SensorPacket{
+	PacketIROpCode, PacketIROpCodeLeft, PacketIROpCodeRight,
+	PacketChargerAvailable, PacketChargingState,
+}
+
+// =============================================================================
+
+// DockController sends the robot home to its charger, confirms that it
+// arrived, and retries if it did not.
+type DockController struct {
+	o        *OIBot
+	config   DockConfig
+	mu       sync.Mutex
+	running  bool
+	onResult func(DockResult)
+}
+
+func (o *OIBot) NewDockController(config DockConfig) *DockController {
+	if config.Attempts <= 0 {
+		config.Attempts = DefaultDockConfig.Attempts
+	}
+	if config.AttemptTimeout <= 0 {
+		config.AttemptTimeout = DefaultDockConfig.AttemptTimeout
+	}
+	if config.Period <= 0 {
+		config.Period = DefaultDockConfig.Period
+	}
+	if config.ConfirmSamples <= 0 {
+		config.ConfirmSamples = DefaultDockConfig.ConfirmSamples
+	}
+	return &DockController{o: o, config: config}
+}
+
+// OnResult registers fn to receive the outcome of every docking run,
+// including those started by AutoDock.
+func (d *DockController) OnResult(fn func(DockResult)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onResult = fn
+}
+
+// AutoDock starts docking in the background whenever m reports the charge at
+// or below percent. m must be running for this to have any effect.
+func (d *DockController) AutoDock(ctx context.Context, m *BatteryMonitor, percent float64) {
+	m.OnLow(percent, func(BatteryReport) {
+		go d.DockContext(ctx)
+	})
+}
+
+func (d *DockController) Dock() (*DockResult, error) {
+	return d.DockContext(context.Background())
+}
+
+// DockContext issues Force Seeking Dock and watches the charger and IR
+// sensors until the Home Base is confirmed as a charging source. An attempt
+// that times out is retried after backing away from wherever the robot got
+// stuck. Only one docking run may be in progress at a time.
+func (d *DockController) DockContext(ctx context.Context) (*DockResult, error) {
+	d.mu.Lock()
+	if d.running {
+		d.mu.Unlock()
+		return nil, errors.New("docking already in progress")
+	}
+	d.running = true
+	d.mu.Unlock()
+
+	started := time.Now()
+	result := &DockResult{Outcome: DockFailed}
+	for result.Attempts < d.config.Attempts {
+		result.Attempts++
+		if result.Attempts > 1 {
+			if err := d.backoff(ctx); nil != err {
+				result.Err = err
+				break
+			}
+		}
+		docked, err := d.attempt(ctx, result)
+		if docked {
+			result.Outcome, result.Err = DockSucceeded, nil
+			break
+		}
+		result.Err = err
+		if nil != err && !errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+	}
+	switch {
+	case nil != ctx.Err():
+		result.Outcome, result.Err = DockCanceled, ctx.Err()
+	case DockFailed == result.Outcome && (nil == result.Err || errors.Is(result.Err, context.DeadlineExceeded)):
+		result.Err = fmt.Errorf("%w after %d attempts", ErrDockFailed, result.Attempts)
+	}
+	result.Duration = time.Since(started)
+
+	d.mu.Lock()
+	d.running = false
+	onResult := d.onResult
+	d.mu.Unlock()
+	if nil != onResult {
+		onResult(*result)
+	}
+	return result, result.Err
+}
+
+// attempt runs a single seek, returning context.DeadlineExceeded if it ran out
+// of time without docking.
+func (d *DockController) attempt(ctx context.Context, result *DockResult) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.AttemptTimeout)
+	defer cancel()
+	if err := d.o.SeekDockContext(ctx); nil != err {
+		return false, err
+	}
+	ticker := time.NewTicker(d.config.Period)
+	defer ticker.Stop()
+	confirmed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+		}
+		data, err := d.o.SensorListContext(ctx, dockPacket...)
+		if nil != err {
+			return false, err
+		}
+		s := &SensorData{}
+		if err := s.DecodePackets(dockPacket, data); nil != err {
+			return false, err
+		}
+		if IsDockIR(s.IROpCode) || IsDockIR(s.IROpCodeLeft) || IsDockIR(s.IROpCodeRight) {
+			result.SawDock = true
+		}
+		if s.ChargerAvailable.HomeBase() {
+			confirmed++
+		} else {
+			confirmed = 0
+		}
+		if confirmed >= d.config.ConfirmSamples {
+			return true, nil
+		}
+	}
+}
+
+func (d *DockController) backoff(ctx context.Context) error {
+	if d.config.BackoffMM <= 0 {
+		return nil
+	}
+	if err := d.o.SetModeContext(ctx, OIMSafe); nil != err {
+		return err
+	}
+	err := d.o.DriveDistanceContext(ctx, -d.config.BackoffMM, 100)
+	if errors.Is(err, ErrHazard) {
+		err = nil // the seek itself will find another way around
+	}
+	return err
+}
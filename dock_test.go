@@ -0,0 +1,33 @@
+package oibot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDock(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	sim.SetDockTime(300 * time.Millisecond)
+	d := o.NewDockController(DockConfig{Period: 50 * time.Millisecond, AttemptTimeout: time.Second})
+	r, err := d.Dock()
+	if nil != err || DockSucceeded != r.Outcome || !r.SawDock || 1 != r.Attempts {
+		t.Fatalf("Dock() = %+v, %v", r, err)
+	}
+}
+
+func TestDockRetries(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	sim.Update(func(d *SensorData) { d.ChargerAvailable = 0 })
+	sim.SetDockTime(0) // never reaches the dock
+	d := o.NewDockController(DockConfig{
+		Period:         50 * time.Millisecond,
+		AttemptTimeout: 300 * time.Millisecond,
+		Attempts:       2,
+		BackoffMM:      20,
+	})
+	r, err := d.Dock()
+	if !errors.Is(err, ErrDockFailed) || DockFailed != r.Outcome || 2 != r.Attempts {
+		t.Fatalf("Dock() = %+v, %v; want ErrDockFailed after 2 attempts", r, err)
+	}
+}
@@ -0,0 +1,118 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	MaxDrivePWM int16 = 255
+	MinDrivePWM int16 = -255
+)
+
+var wheelCurrentPacket = []*SensorPacket{PacketRightMotorCurrent, PacketLeftMotorCurrent}
+
+func (o *OIBot) DrivePWM(rightPWM int16, leftPWM int16) error {
+	return o.DrivePWMContext(context.Background(), rightPWM, leftPWM)
+}
+
+// DrivePWMContext drives each wheel at a raw duty cycle in MinDrivePWM through
+// MaxDrivePWM. The robot only honors it in Safe or Full mode; in any other
+// mode it is refused with a ModeError.
+func (o *OIBot) DrivePWMContext(ctx context.Context, rightPWM int16, leftPWM int16) error {
+	if err := validateDrivePWM(rightPWM, leftPWM); nil != err {
+		return err
+	}
+	return o.drivePWM(ctx, rightPWM, leftPWM)
+}
+
+func (o *OIBot) drivePWM(ctx context.Context, rightPWM int16, leftPWM int16) error {
+	if err := o.command(ctx, opcDrivePWM, rightPWM, leftPWM); nil != err {
+		return err
+	}
+	o.setVelocity((rightPWM + leftPWM) / 2)
+	return nil
+}
+
+func validateDrivePWM(rightPWM int16, leftPWM int16) error {
+	if rightPWM < MinDrivePWM || rightPWM > MaxDrivePWM {
+		return fmt.Errorf("%w: right wheel: %d", ErrInvalidPWM, rightPWM)
+	}
+	if leftPWM < MinDrivePWM || leftPWM > MaxDrivePWM {
+		return fmt.Errorf("%w: left wheel: %d", ErrInvalidPWM, leftPWM)
+	}
+	return nil
+}
+
+// =============================================================================
+
+func (o *OIBot) DrivePWMLimited(rightPWM int16, leftPWM int16, maxCurrentmA int16) error {
+	return o.DrivePWMLimitedContext(context.Background(), rightPWM, leftPWM, maxCurrentmA)
+}
+
+// DrivePWMLimitedContext drives the wheels at the given duty cycles until ctx
+// is done, sampling the wheel motor currents every SensorUpdateDelayMS. A
+// wheel drawing more than maxCurrentmA has its duty cycle scaled back in
+// proportion to the excess, then ramped back toward the requested duty cycle
+// once the current falls. The wheels are stopped before returning.
+func (o *OIBot) DrivePWMLimitedContext(ctx context.Context, rightPWM int16, leftPWM int16, maxCurrentmA int16) (err error) {
+	if err := validateDrivePWM(rightPWM, leftPWM); nil != err {
+		return err
+	}
+	if maxCurrentmA <= 0 {
+		return fmt.Errorf("invalid current limit: %d mA", maxCurrentmA)
+	}
+	if err := o.checkMode(opcDrivePWM); nil != err {
+		return err
+	}
+	defer func() {
+		// the robot must stop even if ctx is what ended the drive
+		if stopErr := haltError(o.drivePWM(WithPriority(context.Background()), 0, 0)); nil == err {
+			err = stopErr
+		}
+	}()
+
+	want := [2]int16{rightPWM, leftPWM}
+	out := want
+	for {
+		if err := o.drivePWM(ctx, out[0], out[1]); nil != err {
+			return err
+		}
+		if err := sleepContext(ctx, SensorUpdateDelayMS); nil != err {
+			return err
+		}
+		data, err := o.SensorListContext(ctx, wheelCurrentPacket...)
+		if nil != err {
+			return err
+		}
+		d := &SensorData{}
+		if err := d.DecodePackets(wheelCurrentPacket, data); nil != err {
+			return err
+		}
+		current := [2]int16{d.RightMotorCurrentmA, d.LeftMotorCurrentmA}
+		for i := range out {
+			out[i] = limitPWM(want[i], out[i], current[i], maxCurrentmA)
+		}
+	}
+}
+
+// limitPWM returns the next duty cycle for one wheel: scaled down if the wheel
+// is over its current limit, otherwise a step closer to the requested value.
+func limitPWM(want int16, out int16, currentmA int16, maxCurrentmA int16) int16 {
+	abs := int32(currentmA)
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs > int32(maxCurrentmA) {
+		return int16(int32(out) * int32(maxCurrentmA) / abs)
+	}
+	step := want / 8
+	if 0 == step {
+		step = want
+	}
+	next := out + step
+	if (step > 0 && next > want) || (step < 0 && next < want) {
+		next = want
+	}
+	return next
+}
@@ -0,0 +1,43 @@
+package oibot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimitPWM(t *testing.T) {
+	for _, test := range []struct {
+		want, out, currentmA, next int16
+	}{
+		{255, 255, 250, 102}, // scaled back by the excess
+		{255, 255, -250, 102},
+		{255, 102, 50, 133}, // ramped up an eighth at a time
+		{255, 250, 50, 255},
+		{-255, -102, -50, -133},
+		{3, 0, 0, 3},
+	} {
+		if next := limitPWM(test.want, test.out, test.currentmA, 100); test.next != next {
+			t.Errorf("limitPWM(%d, %d, %d, 100) = %d; want %d", test.want, test.out, test.currentmA, next, test.next)
+		}
+	}
+}
+
+func TestDrivePWMLimited(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.DrivePWMLimited(100, 100, 100); nil == err {
+		t.Fatal("DrivePWMLimited() was accepted in Passive mode")
+	}
+	if err := o.SetMode(OIMFull); nil != err {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := o.DrivePWMLimitedContext(ctx, MaxDrivePWM, MaxDrivePWM, 100); context.DeadlineExceeded != err {
+		t.Fatalf("DrivePWMLimitedContext() = %v; want context.DeadlineExceeded", err)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if v := sim.Sensors().VelocityLeftMMPS; 0 != v {
+		t.Fatalf("robot still driving at %d mm/s", v)
+	}
+}
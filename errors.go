@@ -0,0 +1,39 @@
+package oibot
+
+import "errors"
+
+var (
+	ErrTimeout         = errors.New("oibot: read timed out")
+	ErrInvalidVelocity = errors.New("oibot: invalid drive velocity")
+	ErrInvalidRadius   = errors.New("oibot: invalid drive radius")
+	ErrInvalidBaud     = errors.New("oibot: invalid baud rate")
+	ErrShortWrite      = errors.New("oibot: short write")
+	ErrPortClosed      = errors.New("oibot: port closed")
+	ErrStreamActive    = errors.New("oibot: sensor stream active")
+	ErrOutOfRange      = errors.New("oibot: sensor value out of range")
+	ErrInvalidSong     = errors.New("oibot: invalid song")
+	ErrInvalidLEDs     = errors.New("oibot: invalid LED display")
+	ErrInvalidPWM      = errors.New("oibot: invalid PWM duty cycle")
+	ErrInvalidMode     = errors.New("oibot: command not allowed in current mode")
+	ErrModeTransition  = errors.New("oibot: mode transition failed")
+	ErrInvalidSchedule = errors.New("oibot: invalid schedule")
+	ErrHazard          = errors.New("oibot: motion aborted by hazard sensor")
+	ErrStalled         = errors.New("oibot: motion aborted with wheels stalled")
+	ErrDockFailed      = errors.New("oibot: docking failed")
+	ErrBaudNegotiation = errors.New("oibot: baud rate negotiation failed")
+	ErrDisconnected    = errors.New("oibot: link to robot is down")
+)
+
+// transient reports whether err leaves a poll worth retrying: the robot was
+// busy streaming, did not answer in time, or the link is down and may return.
+func transient(err error) bool {
+	return errors.Is(err, ErrStreamActive) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrDisconnected)
+}
+
+// Must panics through the error logger if err is non-nil. It lets scripts keep
+// the old fail-fast behavior: o.Must(o.Drive(100, 0)).
+func (o *OIBot) Must(err error) {
+	if nil != err {
+		o.errorLog.Panic(err)
+	}
+}
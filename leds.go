@@ -0,0 +1,156 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type LEDs byte
+
+const (
+	LEDDebris     LEDs = 1 << 0
+	LEDSpot       LEDs = 1 << 1
+	LEDDock       LEDs = 1 << 2
+	LEDCheckRobot LEDs = 1 << 3
+)
+
+const (
+	PowerColorGreen    byte = 0
+	PowerColorAmber    byte = 128
+	PowerColorRed      byte = 255
+	PowerIntensityOff  byte = 0
+	PowerIntensityFull byte = 255
+)
+
+type WeekdayLEDs byte
+
+const (
+	LEDSunday    WeekdayLEDs = 1 << 0
+	LEDMonday    WeekdayLEDs = 1 << 1
+	LEDTuesday   WeekdayLEDs = 1 << 2
+	LEDWednesday WeekdayLEDs = 1 << 3
+	LEDThursday  WeekdayLEDs = 1 << 4
+	LEDFriday    WeekdayLEDs = 1 << 5
+	LEDSaturday  WeekdayLEDs = 1 << 6
+)
+
+func WeekdayLED(day time.Weekday) WeekdayLEDs {
+	return WeekdayLEDs(1) << uint(day)
+}
+
+type ScheduleLEDs byte
+
+const (
+	LEDColon    ScheduleLEDs = 1 << 0
+	LEDPM       ScheduleLEDs = 1 << 1
+	LEDAM       ScheduleLEDs = 1 << 2
+	LEDClock    ScheduleLEDs = 1 << 3
+	LEDSchedule ScheduleLEDs = 1 << 4
+)
+
+// Segments selects the segments of one seven-segment digit:
+//
+//	   A
+//	F     B
+//	   G
+//	E     C
+//	   D
+type Segments byte
+
+const (
+	SegmentA Segments = 1 << 0
+	SegmentB Segments = 1 << 1
+	SegmentC Segments = 1 << 2
+	SegmentD Segments = 1 << 3
+	SegmentE Segments = 1 << 4
+	SegmentF Segments = 1 << 5
+	SegmentG Segments = 1 << 6
+)
+
+const (
+	DigitLEDs          int           = 4
+	minDigitASCII      byte          = 32
+	maxDigitASCII      byte          = 126
+	DefaultScrollDelay time.Duration = 300 * time.Millisecond
+)
+
+// =============================================================================
+
+func (o *OIBot) SetLEDs(leds LEDs, powerColor byte, powerIntensity byte) error {
+	return o.SetLEDsContext(context.Background(), leds, powerColor, powerIntensity)
+}
+
+func (o *OIBot) SetLEDsContext(ctx context.Context, leds LEDs, powerColor byte, powerIntensity byte) error {
+	return o.command(ctx, opcLEDs, byte(leds), powerColor, powerIntensity)
+}
+
+func (o *OIBot) SchedulingLEDs(days WeekdayLEDs, icons ScheduleLEDs) error {
+	return o.SchedulingLEDsContext(context.Background(), days, icons)
+}
+
+func (o *OIBot) SchedulingLEDsContext(ctx context.Context, days WeekdayLEDs, icons ScheduleLEDs) error {
+	return o.command(ctx, opcSchedulingLEDs, byte(days), byte(icons))
+}
+
+// DigitLEDsRaw lights the given segments of each digit, leftmost first.
+func (o *OIBot) DigitLEDsRaw(digit [DigitLEDs]Segments) error {
+	return o.DigitLEDsRawContext(context.Background(), digit)
+}
+
+func (o *OIBot) DigitLEDsRawContext(ctx context.Context, digit [DigitLEDs]Segments) error {
+	return o.command(ctx, opcDigitLEDsRaw, digit)
+}
+
+// DigitLEDsASCII shows up to four printable ASCII characters, left-aligned and
+// padded with spaces.
+func (o *OIBot) DigitLEDsASCII(text string) error {
+	return o.DigitLEDsASCIIContext(context.Background(), text)
+}
+
+func (o *OIBot) DigitLEDsASCIIContext(ctx context.Context, text string) error {
+	digit, err := encodeDigitASCII(text)
+	if nil != err {
+		return err
+	}
+	return o.command(ctx, opcDigitLEDsASCII, digit)
+}
+
+func encodeDigitASCII(text string) ([DigitLEDs]byte, error) {
+	digit := [DigitLEDs]byte{' ', ' ', ' ', ' '}
+	if len(text) > DigitLEDs {
+		return digit, fmt.Errorf("%w: %q longer than %d characters", ErrInvalidLEDs, text, DigitLEDs)
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] < minDigitASCII || text[i] > maxDigitASCII {
+			return digit, fmt.Errorf("%w: %q: unprintable character %#x", ErrInvalidLEDs, text, text[i])
+		}
+		digit[i] = text[i]
+	}
+	return digit, nil
+}
+
+func (o *OIBot) ScrollText(text string, delay time.Duration) error {
+	return o.ScrollTextContext(context.Background(), text, delay)
+}
+
+// ScrollTextContext shows text on the digit LEDs, marquee-style if it does not
+// fit, advancing one character every delay until the text has scrolled off.
+func (o *OIBot) ScrollTextContext(ctx context.Context, text string, delay time.Duration) error {
+	if len(text) <= DigitLEDs {
+		return o.DigitLEDsASCIIContext(ctx, text)
+	}
+	if delay <= 0 {
+		delay = DefaultScrollDelay
+	}
+	padded := text + "    "
+	for i := 0; i <= len(text); i++ {
+		if err := o.DigitLEDsASCIIContext(ctx, padded[i:i+DigitLEDs]); nil != err {
+			return err
+		}
+		if err := sleepContext(ctx, delay); nil != err {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ModeTransitionRetries int           = 3
+	ModeTransitionDelay   time.Duration = 100 * time.Millisecond
+)
+
+type modeSet byte
+
+func modes(mode ...OpenInterfaceMode) modeSet {
+	var s modeSet
+	for _, m := range mode {
+		s |= 1 << m
+	}
+	return s
+}
+
+func (s modeSet) has(mode OpenInterfaceMode) bool {
+	return 0 != s&(1<<mode)
+}
+
+var (
+	anyMode    = modes(OIMOff, OIMPassive, OIMSafe, OIMFull)
+	oiModes    = modes(OIMPassive, OIMSafe, OIMFull)
+	driveModes = modes(OIMSafe, OIMFull)
+
+	// modes in which the robot accepts each opcode. Opcodes not listed are
+	// accepted whenever the OI is running.
+	legalModes = map[OpCode]modeSet{
+		opcStart:          anyMode,
+		opcReset:          anyMode,
+		opcDrive:          driveModes,
+		opcDriveWheels:    driveModes,
+		opcDrivePWM:       driveModes,
+		opcMotors:         driveModes,
+		opcPWMMotors:      driveModes,
+		opcLEDs:           driveModes,
+		opcSchedulingLEDs: driveModes,
+		opcDigitLEDsRaw:   driveModes,
+		opcDigitLEDsASCII: driveModes,
+		opcPlay:           driveModes,
+	}
+
+	// the mode each opcode leaves the robot in.
+	nextMode = map[OpCode]OpenInterfaceMode{
+		opcStart:            OIMPassive,
+		opcReset:            OIMOff,
+		opcStop:             OIMOff,
+		opcControl:          OIMSafe,
+		opcSafe:             OIMSafe,
+		opcFull:             OIMFull,
+		opcPower:            OIMPassive,
+		opcSpot:             OIMPassive,
+		opcClean:            OIMPassive,
+		opcMaxClean:         OIMPassive,
+		opcForceSeekingDock: OIMPassive,
+	}
+)
+
+func (m OpenInterfaceMode) String() string {
+	if s, ok := OIModeStr(m); ok {
+		return s
+	}
+	return fmt.Sprintf("MODE(%d)", byte(m))
+}
+
+// ModeError is returned, wrapping ErrInvalidMode, for a command the robot
+// would ignore in its current mode.
+type ModeError struct {
+	Op   OpCode
+	Mode OpenInterfaceMode
+}
+
+func (e *ModeError) Error() string {
+	return fmt.Sprintf("%s: opcode %d in %s mode", ErrInvalidMode, e.Op, e.Mode)
+}
+
+func (e *ModeError) Unwrap() error {
+	return ErrInvalidMode
+}
+
+// ModeChange reports a change of OI mode that the robot made on its own, such
+// as dropping from Safe to Passive on a cliff or wheel drop.
+type ModeChange struct {
+	Time time.Time
+	From OpenInterfaceMode
+	To   OpenInterfaceMode
+}
+
+// haltError discards the ModeError from a stop command: a robot that cannot be
+// driven in its current mode is already stopped.
+func haltError(err error) error {
+	if errors.Is(err, ErrInvalidMode) {
+		return nil
+	}
+	return err
+}
+
+// =============================================================================
+
+// checkMode refuses a sequence of opcodes if any is illegal in the mode that
+// the tracked mode and the opcodes before it leave the robot in. Until the
+// first mode-setting command is sent the mode is unknown and nothing is
+// refused.
+func (o *OIBot) checkMode(code ...OpCode) error {
+	o.mu.Lock()
+	mode, known := o.mode, o.modeKnown
+	o.mu.Unlock()
+	for _, c := range code {
+		legal, ok := legalModes[c]
+		if !ok {
+			legal = oiModes
+		}
+		if known && !legal.has(mode) {
+			return &ModeError{Op: c, Mode: mode}
+		}
+		if next, ok := nextMode[c]; ok {
+			mode, known = next, true
+		}
+	}
+	return nil
+}
+
+func (o *OIBot) trackMode(code ...OpCode) {
+	for _, c := range code {
+		if mode, ok := nextMode[c]; ok {
+			o.mu.Lock()
+			o.mode, o.modeKnown = mode, true
+			o.mu.Unlock()
+		}
+	}
+}
+
+// setTrackedMode replaces the tracked mode without reporting a change.
+func (o *OIBot) setTrackedMode(mode OpenInterfaceMode, known bool) {
+	o.mu.Lock()
+	o.mode, o.modeKnown = mode, known
+	o.mu.Unlock()
+}
+
+// observeMode records a mode read back from the robot, reporting it if it
+// differs from the mode we last put the robot in. It must not be called from
+// a queued job, since the handler may itself send commands.
+func (o *OIBot) observeMode(mode OpenInterfaceMode) {
+	o.mu.Lock()
+	changed := o.modeKnown && mode != o.mode
+	from := o.mode
+	o.mode, o.modeKnown = mode, true
+	fn := o.modeChange
+	o.mu.Unlock()
+	if changed && nil != fn {
+		fn(ModeChange{Time: time.Now(), From: from, To: mode})
+	}
+}
+
+// OnModeChange registers fn to be called whenever the robot is found in a mode
+// other than the one it was last commanded into.
+func (o *OIBot) OnModeChange(fn func(ModeChange)) {
+	o.mu.Lock()
+	o.modeChange = fn
+	o.mu.Unlock()
+}
+
+// TrackedMode returns the mode the robot was last commanded into or observed
+// in, and whether that is known at all.
+func (o *OIBot) TrackedMode() (OpenInterfaceMode, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.mode, o.modeKnown
+}
+
+func (o *OIBot) SetMode(mode OpenInterfaceMode) error {
+	return o.SetModeContext(context.Background(), mode)
+}
+
+// SetModeContext puts the robot in mode and reads the mode back to verify it,
+// retrying up to ModeTransitionRetries times. The robot stops answering
+// queries once it is Off, so that transition cannot be verified.
+func (o *OIBot) SetModeContext(ctx context.Context, mode OpenInterfaceMode) error {
+	var code OpCode
+	switch mode {
+	case OIMOff:
+		return o.StopContext(ctx)
+	case OIMPassive:
+		code = opcStart
+	case OIMSafe:
+		code = opcSafe
+	case OIMFull:
+		code = opcFull
+	default:
+		return fmt.Errorf("%w: cannot enter %s", ErrInvalidMode, mode)
+	}
+	var got OpenInterfaceMode
+	for attempt := 0; attempt <= ModeTransitionRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, ModeTransitionDelay); nil != err {
+				return err
+			}
+		}
+		if current, known := o.TrackedMode(); OIMPassive != mode && (!known || OIMOff == current) {
+			// Safe and Full can only be entered once the OI is started
+			if err := o.StartContext(ctx); nil != err {
+				return err
+			}
+		}
+		if err := o.command(ctx, code); nil != err {
+			return err
+		}
+		var err error
+		if got, err = o.ModeContext(ctx); nil != err {
+			if errors.Is(err, ErrTimeout) {
+				continue
+			}
+			return err
+		}
+		if got == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: robot is %s after %d attempts to enter %s", ErrModeTransition, got, 1+ModeTransitionRetries, mode)
+}
+
+func (o *OIBot) WatchMode(period time.Duration) error {
+	return o.WatchModeContext(context.Background(), period)
+}
+
+// WatchModeContext polls the robot's mode every period until ctx is done,
+// reporting unsolicited changes to the OnModeChange handler. Polls that time
+// out or find the link down are skipped.
+func (o *OIBot) WatchModeContext(ctx context.Context, period time.Duration) error {
+	if period <= 0 {
+		return fmt.Errorf("invalid mode watch period: %s", period)
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		if _, err := o.ModeContext(ctx); nil != err && !transient(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetMode(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	var me *ModeError
+	if err := o.Drive(100, StraightDriveRadiusMM); !errors.As(err, &me) || OIMPassive != me.Mode {
+		t.Fatalf("Drive() in Passive = %v; want a ModeError", err)
+	}
+	for _, mode := range []OpenInterfaceMode{OIMSafe, OIMFull, OIMPassive, OIMSafe} {
+		if err := o.SetMode(mode); nil != err {
+			t.Fatalf("SetMode(%s) = %v", mode, err)
+		}
+		if got := sim.Mode(); mode != got {
+			t.Fatalf("SetMode(%s) left the robot in %s", mode, got)
+		}
+		if got, err := o.Mode(); nil != err || mode != got {
+			t.Fatalf("Mode() after SetMode(%s) = %s, %v", mode, got, err)
+		}
+	}
+	change := make(chan ModeChange, 1)
+	o.OnModeChange(func(c ModeChange) { change <- c })
+	if err := o.Drive(100, StraightDriveRadiusMM); nil != err {
+		t.Fatal(err)
+	}
+	sim.Update(func(d *SensorData) { d.BumpsWheeldrops = WheelDropLeft })
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if _, err := o.Mode(); nil != err {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-change:
+		if OIMSafe != c.From || OIMPassive != c.To {
+			t.Fatalf("ModeChange = %+v; want Safe to Passive", c)
+		}
+	default:
+		t.Fatal("wheel drop in Safe mode was not reported")
+	}
+}
+
+func TestWatchMode(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	change := make(chan ModeChange, 1)
+	o.OnModeChange(func(c ModeChange) { change <- c })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- o.WatchModeContext(ctx, 20*time.Millisecond) }()
+	sim.Update(func(d *SensorData) { d.BumpsWheeldrops = WheelDropRight })
+	select {
+	case c := <-change:
+		if OIMSafe != c.From || OIMPassive != c.To {
+			t.Fatalf("ModeChange = %+v; want Safe to Passive", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WatchMode did not notice the wheel drop")
+	}
+	cancel()
+	if err := <-done; context.Canceled != err {
+		t.Fatalf("WatchModeContext() = %v; want context.Canceled", err)
+	}
+}
+
+func TestWatchModePeriod(t *testing.T) {
+	o, _ := newSimBot(t, nil)
+	for _, period := range []time.Duration{0, -time.Second} {
+		if err := o.WatchMode(period); nil == err {
+			t.Fatalf("WatchMode(%s) was accepted", period)
+		}
+	}
+}
+
+func TestWatchModeOutage(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	r := o.NewReconnector(ReconnectConfig{
+		Dial:       func() (Transport, error) { return sim.Transport(), nil },
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	change := make(chan ModeChange, 1)
+	o.OnModeChange(func(c ModeChange) { change <- c })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- o.WatchModeContext(ctx, 10*time.Millisecond) }()
+	sim.Unplug()
+	expectState(t, ch, Disconnected)
+	expectState(t, ch, Reconnected)
+	sim.Update(func(d *SensorData) { d.BumpsWheeldrops = WheelDropRight })
+	select {
+	case c := <-change:
+		if OIMSafe != c.From || OIMPassive != c.To {
+			t.Fatalf("ModeChange = %+v; want Safe to Passive", c)
+		}
+	case err := <-done:
+		t.Fatalf("WatchModeContext() = %v during the outage", err)
+	case <-time.After(time.Second):
+		t.Fatal("WatchMode did not notice the wheel drop")
+	}
+	cancel()
+	if err := <-done; context.Canceled != err {
+		t.Fatalf("WatchModeContext() = %v; want context.Canceled", err)
+	}
+}
@@ -0,0 +1,222 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MotionConfig tunes the closed-loop motion primitives.
+type MotionConfig struct {
+	MinSpeedMMPS int16         // slowest commanded speed; also used to correct overshoot
+	AccelMMPS2   float64       // acceleration and deceleration limit
+	ToleranceMM  float64       // DriveDistance and ArcTo stop within this distance
+	ToleranceDeg float64       // TurnAngle stops within this angle
+	Period       time.Duration // control loop period
+	// StallTimeout aborts the motion with ErrStalled when the encoders have
+	// not moved for this long, as when a wheel is blocked without a bump.
+	StallTimeout time.Duration
+}
+
+var DefaultMotionConfig = MotionConfig{
+	MinSpeedMMPS: 20,
+	AccelMMPS2:   300,
+	ToleranceMM:  5,
+	ToleranceDeg: 2,
+	Period:       SensorUpdateDelayMS,
+	StallTimeout: time.Second,
+}
+
+var motionPacket = []*SensorPacket{
+	PacketEncoderCountsLeft, PacketEncoderCountsRight, PacketBumpsWheeldrops,
+	PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight,
+}
+
+func (o *OIBot) SetMotionConfig(config MotionConfig) {
+	o.mu.Lock()
+	o.motion = config
+	o.mu.Unlock()
+}
+
+func (o *OIBot) MotionConfig() MotionConfig {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if (MotionConfig{}) == o.motion {
+		return DefaultMotionConfig
+	}
+	return o.motion
+}
+
+// path describes one motion primitive in terms of a reference speed along it:
+// wheels converts a signed reference speed into wheel velocities, and advance
+// converts wheel travel into progress along the reference, both in mm.
+type path struct {
+	target  float64
+	wheels  func(speed float64) (right float64, left float64)
+	advance func(rightMM float64, leftMM float64) float64
+}
+
+// =============================================================================
+
+func (o *OIBot) DriveDistance(distanceMM float64, speed int16) error {
+	return o.DriveDistanceContext(context.Background(), distanceMM, speed)
+}
+
+// DriveDistanceContext drives straight for distanceMM (negative to reverse) at
+// up to speed mm/s, then stops.
+func (o *OIBot) DriveDistanceContext(ctx context.Context, distanceMM float64, speed int16) error {
+	dir := sign(distanceMM)
+	return o.follow(ctx, speed, o.MotionConfig().ToleranceMM, path{
+		target: math.Abs(distanceMM),
+		wheels: func(v float64) (float64, float64) {
+			return dir * v, dir * v
+		},
+		advance: func(r, l float64) float64 {
+			return dir * (r + l) / 2
+		},
+	})
+}
+
+func (o *OIBot) TurnAngle(angleDeg float64, speed int16) error {
+	return o.TurnAngleContext(context.Background(), angleDeg, speed)
+}
+
+// TurnAngleContext rotates in place by angleDeg, counterclockwise positive,
+// with the wheels moving at up to speed mm/s, then stops.
+func (o *OIBot) TurnAngleContext(ctx context.Context, angleDeg float64, speed int16) error {
+	dir, half := sign(angleDeg), float64(DriveWheelSeparationMM)/2
+	return o.follow(ctx, speed, o.MotionConfig().ToleranceDeg*math.Pi/180*half, path{
+		target: math.Abs(angleDeg) * math.Pi / 180 * half,
+		wheels: func(v float64) (float64, float64) {
+			return dir * v, -dir * v
+		},
+		advance: func(r, l float64) float64 {
+			return dir * (r - l) / 2
+		},
+	})
+}
+
+func (o *OIBot) ArcTo(radiusMM float64, angleDeg float64, speed int16) error {
+	return o.ArcToContext(context.Background(), radiusMM, angleDeg, speed)
+}
+
+// ArcToContext drives forward along a circle of radiusMM until the heading has
+// changed by angleDeg, turning left (counterclockwise) for positive angles and
+// right for negative ones. speed limits the faster, outer wheel.
+func (o *OIBot) ArcToContext(ctx context.Context, radiusMM float64, angleDeg float64, speed int16) error {
+	half := float64(DriveWheelSeparationMM) / 2
+	if radiusMM <= half {
+		return fmt.Errorf("%w: arc radius %.0f must exceed half the wheel separation", ErrInvalidRadius, radiusMM)
+	}
+	// progress is measured along the outer wheel's path, so that speed and
+	// the acceleration limit apply to the faster wheel
+	rs, outer := sign(angleDeg)*radiusMM, (radiusMM+half)/radiusMM
+	return o.follow(ctx, speed, o.MotionConfig().ToleranceMM, path{
+		target: (radiusMM + half) * math.Abs(angleDeg) * math.Pi / 180,
+		wheels: func(v float64) (float64, float64) {
+			v /= outer
+			return v * (rs + half) / rs, v * (rs - half) / rs
+		},
+		advance: func(r, l float64) float64 {
+			return outer * (r + l) / 2
+		},
+	})
+}
+
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// =============================================================================
+
+// follow runs the closed loop for p: it samples the encoders and hazard
+// sensors each period, ramps the reference speed up and down within the
+// acceleration limit, backs up slowly after an overshoot, and stops the
+// wheels on completion, cancellation, error, a stall, or a bump, cliff or
+// wheel drop.
+func (o *OIBot) follow(ctx context.Context, speed int16, tolerance float64, p path) (err error) {
+	if speed <= 0 || speed > MaxDriveVelocityMMPS {
+		return fmt.Errorf("%w: %d", ErrInvalidVelocity, speed)
+	}
+	config := o.MotionConfig()
+	minSpeed := math.Min(float64(config.MinSpeedMMPS), float64(speed))
+	if config.StallTimeout <= 0 {
+		config.StallTimeout = DefaultMotionConfig.StallTimeout
+	}
+	defer func() {
+		// the robot must stop even if ctx is what ended the motion
+		if stopErr := haltError(o.DriveWheelsContext(WithPriority(context.Background()), 0, 0)); nil == err {
+			err = stopErr
+		}
+	}()
+
+	var left, right uint16
+	progress, started := 0.0, time.Now()
+	moved := started
+	for first := true; ; first = false {
+		d, err := o.motionSample(ctx)
+		if nil != err {
+			return err
+		}
+		if err := hazard(d); nil != err {
+			return err
+		}
+		if !first {
+			progress += p.advance(EncoderDeltaMM(right, d.EncoderCountsRight), EncoderDeltaMM(left, d.EncoderCountsLeft))
+			if d.EncoderCountsLeft != left || d.EncoderCountsRight != right {
+				moved = time.Now()
+			} else if time.Since(moved) > config.StallTimeout {
+				return fmt.Errorf("%w: encoders unchanged for %s", ErrStalled, config.StallTimeout)
+			}
+		}
+		left, right = d.EncoderCountsLeft, d.EncoderCountsRight
+
+		remaining := p.target - progress
+		if math.Abs(remaining) <= tolerance {
+			return nil
+		}
+		var v float64
+		if remaining < 0 {
+			v = -minSpeed // overshot; creep back
+		} else {
+			ramp := minSpeed + config.AccelMMPS2*time.Since(started).Seconds()
+			brake := math.Sqrt(2 * config.AccelMMPS2 * remaining)
+			v = math.Max(minSpeed, math.Min(float64(speed), math.Min(ramp, brake)))
+		}
+		r, l := p.wheels(v)
+		if err := o.DriveWheelsContext(ctx, int16(math.Round(r)), int16(math.Round(l))); nil != err {
+			return err
+		}
+		if err := sleepContext(ctx, config.Period); nil != err {
+			return err
+		}
+	}
+}
+
+func (o *OIBot) motionSample(ctx context.Context) (*SensorData, error) {
+	data, err := o.SensorListContext(ctx, motionPacket...)
+	if nil != err {
+		return nil, err
+	}
+	d := &SensorData{}
+	if err := d.DecodePackets(motionPacket, data); nil != err {
+		return nil, err
+	}
+	return d, nil
+}
+
+func hazard(d *SensorData) error {
+	switch {
+	case d.BumpsWheeldrops.WheelDrop():
+		return fmt.Errorf("%w: wheel drop", ErrHazard)
+	case d.CliffLeft || d.CliffFrontLeft || d.CliffFrontRight || d.CliffRight:
+		return fmt.Errorf("%w: cliff", ErrHazard)
+	case d.BumpsWheeldrops.Bump():
+		return fmt.Errorf("%w: bump", ErrHazard)
+	}
+	return nil
+}
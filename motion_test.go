@@ -0,0 +1,84 @@
+package oibot
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMotion(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.Safe(); nil != err {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		move     func() error
+		x, y     float64 // mm
+		thetaDeg float64
+	}{
+		{"forward", func() error { return o.DriveDistance(150, 100) }, 150, 0, 0},
+		{"turn", func() error { return o.TurnAngle(90, 100) }, 150, 0, 90},
+		{"reverse", func() error { return o.DriveDistance(-100, 100) }, 150, -100, 90},
+		{"arc", func() error { return o.ArcTo(200, -90, 100) }, 350, 100, 0},
+	} {
+		if err := test.move(); nil != err {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		time.Sleep(2 * SensorUpdateDelayMS)
+		x, y, theta := sim.Pose()
+		// errors accumulate over the moves, and with the latency of each
+		// control step
+		if math.Abs(test.x-x) > 30 || math.Abs(test.y-y) > 30 || math.Abs(test.thetaDeg-theta*180/math.Pi) > 5 {
+			t.Fatalf("%s: robot at (%.0f, %.0f) facing %.1f°; want (%.0f, %.0f) facing %.1f°",
+				test.name, x, y, theta*180/math.Pi, test.x, test.y, test.thetaDeg)
+		}
+		if d := sim.Sensors(); 0 != d.VelocityLeftMMPS || 0 != d.VelocityRightMMPS {
+			t.Fatalf("%s: robot still moving", test.name)
+		}
+	}
+	if err := o.DriveDistance(100, 0); !errors.Is(err, ErrInvalidVelocity) {
+		t.Fatalf("DriveDistance() at 0 mm/s = %v", err)
+	}
+}
+
+func TestMotionHazard(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.Safe(); nil != err {
+		t.Fatal(err)
+	}
+	time.AfterFunc(200*time.Millisecond, func() {
+		sim.Update(func(d *SensorData) { d.BumpsWheeldrops = BumpLeft })
+	})
+	if err := o.DriveDistance(1000, 200); !errors.Is(err, ErrHazard) {
+		t.Fatalf("DriveDistance() into a bump = %v; want ErrHazard", err)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if d := sim.Sensors(); 0 != d.VelocityLeftMMPS || 0 != d.VelocityRightMMPS {
+		t.Fatal("robot still moving after the bump")
+	}
+}
+
+func TestMotionStall(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.Safe(); nil != err {
+		t.Fatal(err)
+	}
+	config := DefaultMotionConfig
+	config.StallTimeout = 100 * time.Millisecond
+	o.SetMotionConfig(config)
+	// at 1 mm/s the encoders tick about twice a second, which looks the same
+	// as a blocked wheel
+	start := time.Now()
+	if err := o.DriveDistance(100, 1); !errors.Is(err, ErrStalled) {
+		t.Fatalf("DriveDistance() = %v; want ErrStalled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("stall noticed after %s", elapsed)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if d := sim.Sensors(); 0 != d.VelocityLeftMMPS || 0 != d.VelocityRightMMPS {
+		t.Fatal("robot still moving after the stall")
+	}
+}
@@ -0,0 +1,138 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+)
+
+type Motors byte
+
+const (
+	MotorSideBrush          Motors = 1 << 0
+	MotorVacuum             Motors = 1 << 1
+	MotorMainBrush          Motors = 1 << 2
+	MotorSideBrushClockwise Motors = 1 << 3
+	MotorMainBrushOutward   Motors = 1 << 4
+)
+
+const (
+	MaxBrushPWM  int8 = 127
+	MinBrushPWM  int8 = -127
+	MaxVacuumPWM int8 = 127
+	MinVacuumPWM int8 = 0
+)
+
+// MotorStatus pairs the duty cycles last commanded for the cleaning motors with
+// the currents measured at the brushes. Motors commands are reported as their
+// full-speed duty cycle equivalent; positive brush duty cycles turn the side
+// brush counterclockwise and the main brush inward.
+type MotorStatus struct {
+	MainBrushPWM       int8
+	SideBrushPWM       int8
+	VacuumPWM          int8
+	MainBrushCurrentmA int16
+	SideBrushCurrentmA int16
+}
+
+var motorStatusPacket = []*SensorPacket{PacketMainBrushCurrent, PacketSideBrushCurrent}
+
+// =============================================================================
+
+func (o *OIBot) SetMotors(motors Motors) error {
+	return o.SetMotorsContext(context.Background(), motors)
+}
+
+func (o *OIBot) SetMotorsContext(ctx context.Context, motors Motors) error {
+	if err := o.command(ctx, opcMotors, byte(motors)); nil != err {
+		return err
+	}
+	o.setMotorPWM(motors.pwm())
+	return nil
+}
+
+// pwm returns the duty cycles the robot runs each motor at for motors.
+func (motors Motors) pwm() (main int8, side int8, vacuum int8) {
+	if 0 != motors&MotorMainBrush {
+		main = MaxBrushPWM
+		if 0 != motors&MotorMainBrushOutward {
+			main = MinBrushPWM
+		}
+	}
+	if 0 != motors&MotorSideBrush {
+		side = MaxBrushPWM
+		if 0 != motors&MotorSideBrushClockwise {
+			side = MinBrushPWM
+		}
+	}
+	if 0 != motors&MotorVacuum {
+		vacuum = MaxVacuumPWM
+	}
+	return main, side, vacuum
+}
+
+func (o *OIBot) PWMMotors(mainBrush int8, sideBrush int8, vacuum int8) error {
+	return o.PWMMotorsContext(context.Background(), mainBrush, sideBrush, vacuum)
+}
+
+func (o *OIBot) PWMMotorsContext(ctx context.Context, mainBrush int8, sideBrush int8, vacuum int8) error {
+	if err := validatePWMMotors(mainBrush, sideBrush, vacuum); nil != err {
+		return err
+	}
+	if err := o.command(ctx, opcPWMMotors, mainBrush, sideBrush, vacuum); nil != err {
+		return err
+	}
+	o.setMotorPWM(mainBrush, sideBrush, vacuum)
+	return nil
+}
+
+func validatePWMMotors(mainBrush int8, sideBrush int8, vacuum int8) error {
+	if mainBrush < MinBrushPWM {
+		return fmt.Errorf("%w: main brush: %d", ErrInvalidPWM, mainBrush)
+	}
+	if sideBrush < MinBrushPWM {
+		return fmt.Errorf("%w: side brush: %d", ErrInvalidPWM, sideBrush)
+	}
+	if vacuum < MinVacuumPWM {
+		return fmt.Errorf("%w: vacuum: %d", ErrInvalidPWM, vacuum)
+	}
+	return nil
+}
+
+func (o *OIBot) setMotorPWM(mainBrush int8, sideBrush int8, vacuum int8) {
+	o.mu.Lock()
+	o.motors = [3]int8{mainBrush, sideBrush, vacuum}
+	o.mu.Unlock()
+}
+
+func (o *OIBot) MotorsOff() error {
+	return o.MotorsOffContext(context.Background())
+}
+
+func (o *OIBot) MotorsOffContext(ctx context.Context) error {
+	return o.SetMotorsContext(WithPriority(ctx), 0)
+}
+
+func (o *OIBot) MotorStatus() (*MotorStatus, error) {
+	return o.MotorStatusContext(context.Background())
+}
+
+func (o *OIBot) MotorStatusContext(ctx context.Context) (*MotorStatus, error) {
+	data, err := o.SensorListContext(ctx, motorStatusPacket...)
+	if nil != err {
+		return nil, err
+	}
+	d := &SensorData{}
+	if err := d.DecodePackets(motorStatusPacket, data); nil != err {
+		return nil, err
+	}
+	o.mu.Lock()
+	pwm := o.motors
+	o.mu.Unlock()
+	return &MotorStatus{
+		MainBrushPWM:       pwm[0],
+		SideBrushPWM:       pwm[1],
+		VacuumPWM:          pwm[2],
+		MainBrushCurrentmA: d.MainBrushMotorCurrentmA,
+		SideBrushCurrentmA: d.SideBrushMotorCurrentmA,
+	}, nil
+}
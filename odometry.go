@@ -0,0 +1,191 @@
+package oibot
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	// DefaultWheelNoise is the variance, in mm², added to a wheel's travel for
+	// every millimeter it turns.
+	DefaultWheelNoise float64 = 0.05
+)
+
+var encoderPacket = []*SensorPacket{PacketEncoderCountsLeft, PacketEncoderCountsRight}
+
+// MMPerEncoderCount is the distance a wheel travels per encoder count.
+func MMPerEncoderCount() float64 {
+	return math.Pi * WheelDiameterMM / EncoderCountsPerRev
+}
+
+// EncoderDeltaMM returns the distance a wheel traveled between two encoder
+// readings. The counters are 16-bit and wrap, so the difference is taken
+// modulo 2^16 and interpreted as signed, which is correct as long as the wheel
+// turns less than half the counter range between readings.
+func EncoderDeltaMM(prev uint16, curr uint16) float64 {
+	return float64(int16(curr-prev)) * MMPerEncoderCount()
+}
+
+// Pose is a position in millimeters and heading in radians, counterclockwise
+// positive, in the frame where odometry started.
+type Pose struct {
+	X     float64
+	Y     float64
+	Theta float64
+}
+
+// OdometryCheck compares the motion integrated from the encoders with the
+// Distance and Angle packets over the same interval.
+type OdometryCheck struct {
+	EncoderDistanceMM  float64
+	ReportedDistanceMM float64
+	EncoderAngleDeg    float64
+	ReportedAngleDeg   float64
+}
+
+func (c OdometryCheck) DistanceErrorMM() float64 {
+	return c.ReportedDistanceMM - c.EncoderDistanceMM
+}
+
+func (c OdometryCheck) AngleErrorDeg() float64 {
+	return c.ReportedAngleDeg - c.EncoderAngleDeg
+}
+
+// =============================================================================
+
+// Odometry dead-reckons the robot's pose from wheel encoder counts, tracking
+// the pose covariance with the usual differential-drive error model in which
+// each wheel's variance grows with the distance it travels.
+type Odometry struct {
+	// WheelNoise is the variance added per millimeter of wheel travel.
+	WheelNoise float64
+	// FuseWeight, between 0 and 1, is how far CrossCheck pulls the heading
+	// toward the robot's own Angle packet. Zero only reports the difference.
+	FuseWeight float64
+
+	pose     Pose
+	cov      [3][3]float64
+	left     uint16
+	right    uint16
+	started  bool
+	distance float64
+	angle    float64
+}
+
+func NewOdometry() *Odometry {
+	return &Odometry{WheelNoise: DefaultWheelNoise}
+}
+
+// Reset places the robot at pose with no uncertainty. The next encoder
+// reading becomes the new reference.
+func (od *Odometry) Reset(pose Pose) {
+	od.pose = pose
+	od.cov = [3][3]float64{}
+	od.started = false
+	od.distance, od.angle = 0, 0
+}
+
+func (od *Odometry) Pose() Pose {
+	return od.pose
+}
+
+func (od *Odometry) Covariance() [3][3]float64 {
+	return od.cov
+}
+
+// Update integrates a new pair of encoder readings and returns the distance
+// each wheel traveled since the previous pair. The first reading after a
+// Reset only establishes the reference.
+func (od *Odometry) Update(left uint16, right uint16) (leftMM float64, rightMM float64) {
+	if !od.started {
+		od.left, od.right, od.started = left, right, true
+		return 0, 0
+	}
+	leftMM, rightMM = EncoderDeltaMM(od.left, left), EncoderDeltaMM(od.right, right)
+	od.left, od.right = left, right
+	od.integrate(leftMM, rightMM)
+	return leftMM, rightMM
+}
+
+func (od *Odometry) UpdateSensorData(d *SensorData) (leftMM float64, rightMM float64) {
+	return od.Update(d.EncoderCountsLeft, d.EncoderCountsRight)
+}
+
+func (od *Odometry) integrate(dl float64, dr float64) {
+	b := float64(DriveWheelSeparationMM)
+	dc, dth := (dl+dr)/2, (dr-dl)/b
+	phi := od.pose.Theta + dth/2
+	sin, cos := math.Sin(phi), math.Cos(phi)
+
+	// Jacobians of the motion model with respect to the pose and to the two
+	// wheel displacements (right, left).
+	fx := [3][3]float64{
+		{1, 0, -dc * sin},
+		{0, 1, dc * cos},
+		{0, 0, 1},
+	}
+	fu := [3][2]float64{
+		{cos/2 - dc*sin/(2*b), cos/2 + dc*sin/(2*b)},
+		{sin/2 + dc*cos/(2*b), sin/2 - dc*cos/(2*b)},
+		{1 / b, -1 / b},
+	}
+	q := [2]float64{od.WheelNoise * math.Abs(dr), od.WheelNoise * math.Abs(dl)}
+
+	var cov [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					cov[i][j] += fx[i][k] * od.cov[k][l] * fx[j][l]
+				}
+			}
+			for k := 0; k < 2; k++ {
+				cov[i][j] += fu[i][k] * q[k] * fu[j][k]
+			}
+		}
+	}
+	od.cov = cov
+
+	od.pose.X += dc * cos
+	od.pose.Y += dc * sin
+	od.pose.Theta = math.Remainder(od.pose.Theta+dth, 2*math.Pi)
+	od.distance += dc
+	od.angle += dth * 180 / math.Pi
+}
+
+// CrossCheck compares the encoder motion accumulated since the previous
+// CrossCheck with the robot's Distance (19) and Angle (20) packets, which
+// themselves count from the previous time they were read. With a nonzero
+// FuseWeight the heading is corrected toward the reported angle.
+func (od *Odometry) CrossCheck(distanceMM int16, angleDeg int16) OdometryCheck {
+	check := OdometryCheck{
+		EncoderDistanceMM:  od.distance,
+		ReportedDistanceMM: float64(distanceMM),
+		EncoderAngleDeg:    od.angle,
+		ReportedAngleDeg:   float64(angleDeg),
+	}
+	if od.FuseWeight > 0 {
+		correction := od.FuseWeight * check.AngleErrorDeg() * math.Pi / 180
+		od.pose.Theta = math.Remainder(od.pose.Theta+correction, 2*math.Pi)
+	}
+	od.distance, od.angle = 0, 0
+	return check
+}
+
+// =============================================================================
+
+func (o *OIBot) Encoders() (left uint16, right uint16, err error) {
+	return o.EncodersContext(context.Background())
+}
+
+func (o *OIBot) EncodersContext(ctx context.Context) (left uint16, right uint16, err error) {
+	data, err := o.SensorListContext(ctx, encoderPacket...)
+	if nil != err {
+		return 0, 0, err
+	}
+	d := &SensorData{}
+	if err := d.DecodePackets(encoderPacket, data); nil != err {
+		return 0, 0, err
+	}
+	return d.EncoderCountsLeft, d.EncoderCountsRight, nil
+}
@@ -0,0 +1,117 @@
+package oibot
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncoderDeltaMM(t *testing.T) {
+	mm := MMPerEncoderCount()
+	for _, test := range []struct {
+		prev, curr uint16
+		want       float64
+	}{
+		{0, 100, 100 * mm},
+		{100, 0, -100 * mm},
+		{65530, 4, 10 * mm},
+		{4, 65530, -10 * mm},
+		{32768, 0, -32768 * mm},
+		{1000, 1000, 0},
+	} {
+		if got := EncoderDeltaMM(test.prev, test.curr); math.Abs(test.want-got) > 1e-9 {
+			t.Errorf("EncoderDeltaMM(%d, %d) = %g; want %g", test.prev, test.curr, got, test.want)
+		}
+	}
+}
+
+func TestOdometryIntegrate(t *testing.T) {
+	const noise = 0.05
+	b := float64(DriveWheelSeparationMM)
+	for _, test := range []struct {
+		name string
+		step [][2]float64 // left, right mm
+		want Pose
+		// variances of x, y and theta
+		varX, varY, varTheta float64
+	}{
+		{
+			name: "straight",
+			step: [][2]float64{{100, 100}},
+			want: Pose{X: 100},
+			varX: noise * 100 / 2, varY: noise * 100 * 100 * 100 / (2 * b * b), varTheta: 2 * noise * 100 / (b * b),
+		},
+		{
+			name: "spin",
+			step: [][2]float64{{-b * math.Pi / 4, b * math.Pi / 4}},
+			want: Pose{Theta: math.Pi / 2},
+			// the wheel errors move the center along the heading halfway
+			// through the turn, at 45 degrees
+			varX: noise * b * math.Pi / 16, varY: noise * b * math.Pi / 16, varTheta: noise * math.Pi / (2 * b),
+		},
+		{
+			name: "arc",
+			step: [][2]float64{{0, b * math.Pi / 4}, {0, b * math.Pi / 4}},
+			// each step advances along the heading at its midpoint
+			want: Pose{
+				X:     b * math.Pi / 8 * (math.Cos(math.Pi/8) + math.Cos(3*math.Pi/8)),
+				Y:     b * math.Pi / 8 * (math.Sin(math.Pi/8) + math.Sin(3*math.Pi/8)),
+				Theta: math.Pi / 2,
+			},
+		},
+		{
+			name: "wrap",
+			step: [][2]float64{{-b * math.Pi / 2, b * math.Pi / 2}, {-b * math.Pi / 4, b * math.Pi / 4}},
+			want: Pose{Theta: -math.Pi / 2},
+		},
+	} {
+		od := NewOdometry()
+		for _, s := range test.step {
+			od.integrate(s[0], s[1])
+		}
+		got := od.Pose()
+		if math.Abs(test.want.X-got.X) > 1e-6 || math.Abs(test.want.Y-got.Y) > 1e-6 || math.Abs(test.want.Theta-got.Theta) > 1e-9 {
+			t.Errorf("%s: pose %+v; want %+v", test.name, got, test.want)
+		}
+		cov := od.Covariance()
+		for i := 0; i < 3; i++ {
+			if cov[i][i] < 0 {
+				t.Errorf("%s: negative variance %g on axis %d", test.name, cov[i][i], i)
+			}
+			for j := 0; j < i; j++ {
+				if math.Abs(cov[i][j]-cov[j][i]) > 1e-9 {
+					t.Errorf("%s: covariance not symmetric: %v", test.name, cov)
+				}
+			}
+		}
+		if 0 == test.varTheta {
+			continue
+		}
+		for i, want := range []float64{test.varX, test.varY, test.varTheta} {
+			if math.Abs(want-cov[i][i]) > 1e-9*math.Max(1, want) {
+				t.Errorf("%s: variance %d = %g; want %g", test.name, i, cov[i][i], want)
+			}
+		}
+	}
+}
+
+func TestOdometryUpdate(t *testing.T) {
+	od := NewOdometry()
+	if l, r := od.Update(65530, 65530); 0 != l || 0 != r {
+		t.Fatalf("first Update() = %g, %g; want only the reference taken", l, r)
+	}
+	l, r := od.Update(4, 4) // both counters wrapped
+	if want := 10 * MMPerEncoderCount(); math.Abs(want-l) > 1e-9 || math.Abs(want-r) > 1e-9 {
+		t.Fatalf("Update() = %g, %g; want %g each", l, r, want)
+	}
+	if x := od.Pose().X; math.Abs(10*MMPerEncoderCount()-x) > 1e-9 {
+		t.Fatalf("X = %g after driving across the wrap", x)
+	}
+	check := od.CrossCheck(5, 0)
+	if math.Abs(check.DistanceErrorMM()-(5-check.EncoderDistanceMM)) > 1e-9 || 0 != check.AngleErrorDeg() {
+		t.Fatalf("CrossCheck() = %+v", check)
+	}
+	od.Reset(Pose{X: 1, Y: 2, Theta: 3})
+	if (Pose{X: 1, Y: 2, Theta: 3}) != od.Pose() || ([3][3]float64{}) != od.Covariance() {
+		t.Fatalf("Reset() left %+v, %v", od.Pose(), od.Covariance())
+	}
+}
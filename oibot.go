@@ -2,112 +2,341 @@ package oibot
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"log"
+	"sync"
 	"time"
-
-	"github.com/tarm/serial"
 )
 
+// OIBot is safe for concurrent use. Each command, and each query together with
+// its reply, is sent as a single job on the command queue.
 type OIBot struct {
-	port     *serial.Port
+	queue    *commandQueue
 	infoLog  *log.Logger
 	errorLog *log.Logger
 	path     string
-	baud     int
 	timeout  time.Duration
+	pacer    pacer
+
+	mu       sync.Mutex // guards the fields below
+	port     Transport
+	baud     int
+	closed   bool
+	stream   *SensorStream
+	motors   [3]int8 // main brush, side brush, vacuum
+	motion   MotionConfig
+	velocity int16 // mean commanded wheel velocity, or PWM for Drive PWM
+	pacing   Pacing
+	paced    bool // pacing was set explicitly
+
+	mode       OpenInterfaceMode
+	modeKnown  bool
+	modeChange func(ModeChange)
+
+	fault   func(error)   // reports link failures to a running Reconnector
+	linkErr error         // the link failure not yet recovered from
+	linkUp  chan struct{} // closed once an outage is over
 }
 
-func MakeOIBot(infoLog *log.Logger, errorLog *log.Logger, init bool, path string, baud int, rtime time.Duration) *OIBot {
-	var o *OIBot
+func MakeOIBot(infoLog *log.Logger, errorLog *log.Logger, init bool, path string, baud int, rtime time.Duration) (*OIBot, error) {
 	if _, ok := codeForBaudRate[baud]; !ok {
-		errorLog.Panic(fmt.Errorf("invalid baud rate: %d", baud))
+		return nil, fmt.Errorf("%w: %d", ErrInvalidBaud, baud)
 	}
-	config := &serial.Config{Name: path, Baud: baud}
-	if rtime > NeverReadTimeoutMS {
-		config.ReadTimeout = rtime
-	}
-	if port, err := serial.OpenPort(config); nil != err {
-		errorLog.Panic(fmt.Errorf("failed to open serial port: %s (%d): %s", path, baud, err))
-	} else {
-		o = &OIBot{port: port, infoLog: infoLog, errorLog: errorLog, path: path, baud: baud, timeout: rtime}
-		if init {
-			o.Baud(baud)
+	port, err := OpenSerialTransport(path, baud, rtime)
+	if nil != err {
+		return nil, err
+	}
+	o, err := MakeOIBotTransport(infoLog, errorLog, init, port, baud, rtime)
+	if nil != err {
+		port.Close()
+		return nil, err
+	}
+	o.path = path
+	return o, nil
+}
+
+func MakeOIBotTransport(infoLog *log.Logger, errorLog *log.Logger, init bool, port Transport, baud int, rtime time.Duration) (*OIBot, error) {
+	if _, ok := codeForBaudRate[baud]; !ok {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidBaud, baud)
+	}
+	o := &OIBot{port: port, queue: newCommandQueue(), infoLog: infoLog, errorLog: errorLog, baud: baud, timeout: rtime}
+	if init {
+		// the robot may be at any rate: still at the last one set, back at
+		// its 115200 default after a reset, or at 19200 from the BRC pin.
+		// Probing needs a read timeout, so without one the rate is only set.
+		negotiate := o.Baud
+		if rtime > NeverReadTimeoutMS {
+			negotiate = o.NegotiateBaud
 		}
-		o.Passive()
+		if err := negotiate(baud); nil != err {
+			o.queue.close()
+			return nil, err
+		}
+	}
+	// without a read timeout an unresponsive robot would block verification
+	// forever, so only then is Passive left unverified.
+	start := o.Passive
+	if rtime > NeverReadTimeoutMS {
+		start = func() error { return o.SetMode(OIMPassive) }
+	}
+	if err := start(); nil != err {
+		o.queue.close()
+		return nil, err
+	}
+	return o, nil
+}
+
+func MustMakeOIBot(infoLog *log.Logger, errorLog *log.Logger, init bool, path string, baud int, rtime time.Duration) *OIBot {
+	o, err := MakeOIBot(infoLog, errorLog, init, path, baud, rtime)
+	if nil != err {
+		errorLog.Panic(err)
 	}
 	return o
 }
 
-func (o *OIBot) Flush() {
-	if err := o.port.Flush(); nil != err {
-		o.errorLog.Panic(fmt.Errorf("failed to flush serial port: %s", err))
+func (o *OIBot) isClosed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.closed
+}
+
+func (o *OIBot) transport() Transport {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.port
+}
+
+func (o *OIBot) Flush() error {
+	return o.queue.do(context.Background(), func(context.Context) error {
+		return o.flush()
+	})
+}
+
+func (o *OIBot) flush() error {
+	if o.isClosed() {
+		return ErrPortClosed
+	}
+	if err := o.transport().Flush(); nil != err {
+		return fmt.Errorf("failed to flush serial port: %w", err)
 	}
+	return nil
 }
 
-func (o *OIBot) Close() {
-	if err := o.port.Close(); nil != err {
-		o.errorLog.Panic(fmt.Errorf("failed to close serial port: %s", err))
+// Close closes the port, which also unblocks a read in progress, then fails
+// every command still waiting in the queue with ErrPortClosed.
+func (o *OIBot) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return ErrPortClosed
+	}
+	o.closed = true
+	o.mu.Unlock()
+	err := o.transport().Close()
+	o.queue.close()
+	if nil != err {
+		return fmt.Errorf("failed to close serial port: %w", err)
 	}
+	return nil
 }
 
-func (o *OIBot) Pack(data ...interface{}) []byte {
+func (o *OIBot) Pack(data ...interface{}) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	for _, bin := range data {
 		if err := binary.Write(buf, binary.BigEndian, bin); nil != err {
-			o.errorLog.Panic(fmt.Errorf("failed to pack binary data: ", err))
+			return nil, fmt.Errorf("failed to pack binary data: %w", err)
 		}
 	}
-	return buf.Bytes()
+	return buf.Bytes(), nil
+}
+
+func (o *OIBot) WriteCode(code OpCode) error {
+	return o.WriteCodeContext(context.Background(), code)
+}
+
+func (o *OIBot) WriteCodeContext(ctx context.Context, code OpCode) error {
+	return o.queue.do(ctx, func(ctx context.Context) error {
+		_, err := o.write(ctx, code, nil)
+		return err
+	})
+}
+
+func (o *OIBot) Write(code OpCode, buf ...interface{}) (int, error) {
+	return o.WriteContext(context.Background(), code, buf...)
+}
+
+func (o *OIBot) WriteContext(ctx context.Context, code OpCode, buf ...interface{}) (int, error) {
+	bin, err := o.Pack(buf...)
+	if nil != err {
+		return 0, err
+	}
+	var n int
+	err = o.queue.do(ctx, func(ctx context.Context) error {
+		n, err = o.write(ctx, code, bin)
+		return err
+	})
+	return n, err
+}
+
+// write sends code and its packed arguments in a single write. It returns the
+// number of argument bytes written.
+func (o *OIBot) write(ctx context.Context, code OpCode, bin []byte) (int, error) {
+	if err := o.transmit(ctx, []encodedCommand{{code: code, args: bin}}); nil != err {
+		return 0, err
+	}
+	return len(bin), nil
 }
 
-func (o *OIBot) WriteCode(code OpCode) {
-	if n, err := o.port.Write([]byte{byte(code)}); n != 1 || nil != err {
-		o.errorLog.Panic(fmt.Errorf("failed to write opcode (%d) to serial port: %s", code, err))
+// encodedCommand is an opcode with its arguments already validated and packed.
+type encodedCommand struct {
+	code OpCode
+	args []byte
+}
+
+func joinCommands(command []encodedCommand) []byte {
+	var buf []byte
+	for _, c := range command {
+		buf = append(append(buf, byte(c.code)), c.args...)
 	}
-	time.Sleep(SerialTransferDelayMS)
+	return buf
 }
 
-func (o *OIBot) Write(code OpCode, buf ...interface{}) int {
-	o.WriteCode(code)
-	bin := o.Pack(buf...)
-	o.infoLog.Printf("%+v", bin)
-	count := 0
-	if n, err := o.port.Write(bin); n != len(bin) || nil != err {
-		o.errorLog.Panic(fmt.Errorf("failed to write opcode (%d) data to serial port: %s", code, err))
-	} else {
-		count = n
+// transmit sends every command in a single write, once the previous write has
+// cleared the line. Each command must be legal in the mode the commands before
+// it leave the robot in.
+func (o *OIBot) transmit(ctx context.Context, command []encodedCommand) error {
+	if o.isClosed() {
+		return ErrPortClosed
+	}
+	if err := o.linkError(); nil != err {
+		return err
+	}
+	code := make([]OpCode, len(command))
+	for i, c := range command {
+		code[i] = c.code
+	}
+	buf := joinCommands(command)
+	if err := o.checkMode(code...); nil != err {
+		return err
+	}
+	if err := o.pacer.wait(ctx); nil != err {
+		return err
+	}
+	o.infoLog.Printf("%+v", buf)
+	n, err := o.transport().Write(buf)
+	if n > 0 {
+		o.pacer.sent(o.Pacing().Spacing(o.BaudRate(), n, code...))
+	}
+	if nil != err {
+		return o.linkLost(fmt.Sprintf("failed to write opcode (%d) to serial port", code[0]), err)
+	} else if n != len(buf) {
+		return fmt.Errorf("%w: opcode (%d): %d of %d bytes", ErrShortWrite, code[0], n, len(buf))
 	}
-	time.Sleep(SerialTransferDelayMS)
-	return count
+	o.trackMode(code...)
+	return nil
+}
+
+func (o *OIBot) Read(buf []byte) (int, error) {
+	var n int
+	err := o.queue.do(context.Background(), func(context.Context) error {
+		var err error
+		n, err = o.read(buf)
+		return err
+	})
+	return n, err
 }
 
-func (o *OIBot) Read(buf []byte) int {
-	count := 0
-	if n, err := o.port.Read(buf); nil != err {
-		o.errorLog.Panic(fmt.Errorf("failed to read from serial port: %+v", err))
-	} else {
-		count = n
+func (o *OIBot) read(buf []byte) (int, error) {
+	if o.isClosed() {
+		return 0, ErrPortClosed
+	}
+	if err := o.linkError(); nil != err {
+		return 0, err
+	}
+	start := time.Now()
+	n, err := o.transport().Read(buf)
+	if nil == err && n > 0 {
+		return n, nil
+	}
+	// tarm/serial reports an expired ReadTimeout as zero bytes (with io.EOF on
+	// POSIX systems), so a timeout is only distinguishable from a hangup by
+	// whether or not we asked for one, and by the read taking that long.
+	if nil == err || io.EOF == err {
+		if 0 == n && o.timeout > NeverReadTimeoutMS && time.Since(start) >= o.timeout/2 {
+			return n, ErrTimeout
+		}
+		if io.EOF == err {
+			return n, o.linkLost("serial port hung up", err)
+		}
+		return n, nil
+	}
+	if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+		return n, ErrTimeout
 	}
-	return count
+	return n, o.linkLost("failed to read from serial port", err)
 }
 
-func (o *OIBot) Sensor(packet *SensorPacket) []byte {
-	defer func() {
-		if o.timeout > NeverReadTimeoutMS {
-			recover()
+// linkLost reports a transport that failed under a read or write. That is only
+// ErrPortClosed if it was the OIBot that was closed; otherwise the link was
+// lost and may yet be reopened.
+func (o *OIBot) linkLost(what string, err error) error {
+	if o.isClosed() {
+		return ErrPortClosed
+	}
+	o.linkDown(err)
+	return fmt.Errorf("%w: %s: %w", ErrDisconnected, what, err)
+}
+
+// readFull fills buf, giving up when ctx is done or the read timeout expires.
+// A blocked read is unblocked by expiring the transport's read deadline if it
+// has one, otherwise by the port's own ReadTimeout. Any partial response is
+// flushed so that it cannot be mistaken for the reply to the next query.
+func (o *OIBot) readFull(ctx context.Context, buf []byte) error {
+	parent := ctx
+	if o.timeout > NeverReadTimeoutMS {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	release := interruptRead(ctx, o.transport())
+	defer release()
+	for current := 0; current < len(buf); {
+		if nil != ctx.Err() {
+			return o.abandonRead(parent)
 		}
-	}()
-	o.Write(opcQuery, packet.id)
-	data := make([]byte, packet.size)
-	current, remaining := 0, int(packet.size)
-	for current < remaining {
-		frame := data[current:]
-		remaining -= current
-		current = o.Read(frame)
+		n, err := o.read(buf[current:])
+		current += n
+		if nil != err {
+			if nil != ctx.Err() {
+				return o.abandonRead(parent)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OIBot) abandonRead(ctx context.Context) error {
+	o.transport().Flush()
+	if err := ctx.Err(); nil != err {
+		return err
 	}
-	return data
+	return ErrTimeout
+}
+
+func (o *OIBot) Sensor(packet *SensorPacket) ([]byte, error) {
+	return o.SensorContext(context.Background(), packet)
+}
+
+func (o *OIBot) SensorContext(ctx context.Context, packet *SensorPacket) ([]byte, error) {
+	data, err := o.query(ctx, opcQuery, []*SensorPacket{packet}, packet.id)
+	if nil != err {
+		return nil, err
+	}
+	return data[0], nil
 }
 
 func (o *OIBot) sensorListID(packet ...*SensorPacket) []byte {
@@ -118,123 +347,292 @@ func (o *OIBot) sensorListID(packet ...*SensorPacket) []byte {
 	return id
 }
 
-func (o *OIBot) SensorList(packet ...*SensorPacket) [][]byte {
-	defer func() {
-		if o.timeout > NeverReadTimeoutMS {
-			recover()
-		}
-	}()
+func (o *OIBot) SensorList(packet ...*SensorPacket) ([][]byte, error) {
+	return o.SensorListContext(context.Background(), packet...)
+}
+
+func (o *OIBot) SensorListContext(ctx context.Context, packet ...*SensorPacket) ([][]byte, error) {
 	numPackets := byte(len(packet))
-	if numPackets > 0 {
-		queryList := []byte{numPackets}
-		queryList = append(queryList, o.sensorListID(packet...)...)
-		o.Write(opcQueryList, queryList)
-		data := make([][]byte, numPackets)
-		for i, p := range packet {
-			data[i] = make([]byte, p.size)
-			current, remaining := 0, int(p.size)
-			for current < remaining {
-				frame := data[i][current:]
-				remaining -= current
-				current = o.Read(frame)
-			}
+	if 0 == numPackets {
+		return nil, nil
+	}
+	queryList := []byte{numPackets}
+	queryList = append(queryList, o.sensorListID(packet...)...)
+	return o.query(ctx, opcQueryList, packet, queryList)
+}
+
+// query sends a Query or Query List and reads the reply to each packet in the
+// same job, so no other command can come between them.
+func (o *OIBot) query(ctx context.Context, code OpCode, packet []*SensorPacket, request interface{}) ([][]byte, error) {
+	bin, err := o.Pack(request)
+	if nil != err {
+		return nil, err
+	}
+	var data [][]byte
+	err = o.queue.do(ctx, func(ctx context.Context) error {
+		data, err = o.exchange(ctx, code, packet, bin)
+		return err
+	})
+	if nil != err {
+		return nil, err
+	}
+	return data, nil
+}
+
+// exchange is the body of query, for use inside a job.
+func (o *OIBot) exchange(ctx context.Context, code OpCode, packet []*SensorPacket, bin []byte) ([][]byte, error) {
+	o.mu.Lock()
+	streaming := nil != o.stream
+	o.mu.Unlock()
+	if streaming {
+		return nil, ErrStreamActive
+	}
+	return o.transact(ctx, code, packet, bin)
+}
+
+// transact is exchange without the check for an active stream, for callers
+// that know the robot is not streaming whatever o.stream says.
+func (o *OIBot) transact(ctx context.Context, code OpCode, packet []*SensorPacket, bin []byte) ([][]byte, error) {
+	o.transport().Flush() // discard any reply left behind by an abandoned query
+	if _, err := o.write(ctx, code, bin); nil != err {
+		return nil, err
+	}
+	data := make([][]byte, len(packet))
+	for i, p := range packet {
+		data[i] = make([]byte, p.size)
+		if err := o.readFull(ctx, data[i]); nil != err {
+			return nil, err
 		}
-		return data
 	}
-	return nil
+	return data, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 // =============================================================================
 
-func (o *OIBot) Start() {
-	o.Write(opcStart)
+func (o *OIBot) command(ctx context.Context, code OpCode, buf ...interface{}) error {
+	_, err := o.WriteContext(ctx, code, buf...)
+	return err
+}
+
+func (o *OIBot) Start() error {
+	return o.StartContext(context.Background())
+}
+
+func (o *OIBot) StartContext(ctx context.Context) error {
+	return o.command(ctx, opcStart)
+}
+
+func (o *OIBot) Passive() error { // alias for start command
+	return o.PassiveContext(context.Background())
 }
 
-func (o *OIBot) Passive() { // alias for start command
-	o.Write(opcStart)
+func (o *OIBot) PassiveContext(ctx context.Context) error {
+	return o.command(ctx, opcStart)
 }
 
-func (o *OIBot) Reset() {
-	o.Write(opcReset)
+func (o *OIBot) Reset() error {
+	return o.ResetContext(context.Background())
 }
 
-func (o *OIBot) Stop() {
-	o.Write(opcStop)
+func (o *OIBot) ResetContext(ctx context.Context) error {
+	return o.command(ctx, opcReset)
 }
 
-func (o *OIBot) Baud(baud int) {
-	if code, ok := codeForBaudRate[baud]; !ok {
-		o.errorLog.Panic(fmt.Errorf("will not change to invalid baud rate: %d", baud))
-	} else {
-		_ = o.Write(opcBaud, code)
-		time.Sleep(100 * time.Millisecond)
+func (o *OIBot) Stop() error {
+	return o.StopContext(context.Background())
+}
+
+func (o *OIBot) StopContext(ctx context.Context) error {
+	return o.command(ctx, opcStop)
+}
+
+func (o *OIBot) Baud(baud int) error {
+	return o.BaudContext(context.Background(), baud)
+}
+
+// BaudContext changes the robot's baud rate and then the host port's to match.
+// It does not verify that the robot followed; NegotiateBaud does.
+func (o *OIBot) BaudContext(ctx context.Context, baud int) error {
+	if _, ok := codeForBaudRate[baud]; !ok {
+		return fmt.Errorf("%w: will not change to %d", ErrInvalidBaud, baud)
 	}
+	return o.queue.do(ctx, func(ctx context.Context) error {
+		return o.changeBaud(ctx, baud)
+	})
+}
+
+func (o *OIBot) BaudRate() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.baud
+}
+
+func (o *OIBot) Control() error {
+	return o.ControlContext(context.Background())
+}
+
+func (o *OIBot) ControlContext(ctx context.Context) error {
+	return o.command(ctx, opcControl)
+}
+
+func (o *OIBot) Safe() error {
+	return o.SafeContext(context.Background())
+}
+
+func (o *OIBot) SafeContext(ctx context.Context) error {
+	return o.command(ctx, opcSafe)
+}
+
+func (o *OIBot) Full() error {
+	return o.FullContext(context.Background())
 }
 
-func (o *OIBot) Control() {
-	o.Write(opcControl)
+func (o *OIBot) FullContext(ctx context.Context) error {
+	return o.command(ctx, opcFull)
 }
 
-func (o *OIBot) Safe() {
-	o.Write(opcSafe)
+func (o *OIBot) Power() error {
+	return o.PowerContext(context.Background())
 }
 
-func (o *OIBot) Full() {
-	o.Write(opcFull)
+func (o *OIBot) PowerContext(ctx context.Context) error {
+	return o.command(ctx, opcPower)
 }
 
-func (o *OIBot) Power() {
-	o.Write(opcPower)
+func (o *OIBot) Clean() error {
+	return o.CleanContext(context.Background())
 }
 
-func (o *OIBot) Clean() {
-	o.Write(opcClean)
+func (o *OIBot) CleanContext(ctx context.Context) error {
+	return o.command(ctx, opcClean)
 }
 
-func (o *OIBot) MaxClean() {
-	o.Write(opcMaxClean)
+func (o *OIBot) MaxClean() error {
+	return o.MaxCleanContext(context.Background())
 }
 
-func (o *OIBot) Spot() {
-	o.Write(opcSpot)
+func (o *OIBot) MaxCleanContext(ctx context.Context) error {
+	return o.command(ctx, opcMaxClean)
 }
 
-func (o *OIBot) SeekDock() {
-	o.Write(opcForceSeekingDock)
+func (o *OIBot) Spot() error {
+	return o.SpotContext(context.Background())
 }
 
-func (o *OIBot) Drive(velocity int16, radius int16) {
+func (o *OIBot) SpotContext(ctx context.Context) error {
+	return o.command(ctx, opcSpot)
+}
+
+func (o *OIBot) SeekDock() error {
+	return o.SeekDockContext(context.Background())
+}
+
+func (o *OIBot) SeekDockContext(ctx context.Context) error {
+	return o.command(ctx, opcForceSeekingDock)
+}
+
+func (o *OIBot) Drive(velocity int16, radius int16) error {
+	return o.DriveContext(context.Background(), velocity, radius)
+}
+
+func (o *OIBot) DriveContext(ctx context.Context, velocity int16, radius int16) error {
+	if err := validateDrive(velocity, radius); nil != err {
+		return err
+	}
+	if err := o.command(ctx, opcDrive, velocity, radius); nil != err {
+		return err
+	}
+	o.setVelocity(driveVelocity(velocity, radius))
+	return nil
+}
+
+func validateDrive(velocity int16, radius int16) error {
 	if velocity < MinDriveVelocityMMPS || velocity > MaxDriveVelocityMMPS {
-		o.errorLog.Panic(fmt.Errorf("invalid drive velocity: %d", velocity))
+		return fmt.Errorf("%w: %d", ErrInvalidVelocity, velocity)
 	}
 	if StraightDriveRadiusMM != radius {
 		if radius < MinDriveRadiusMM || radius > MaxDriveRadiusMM {
-			o.errorLog.Panic(fmt.Errorf("invalid drive radius: %d", radius))
+			return fmt.Errorf("%w: %d", ErrInvalidRadius, radius)
 		}
 	}
-	_ = o.Write(opcDrive, velocity, radius)
+	return nil
+}
+
+// driveVelocity is the forward speed of a Drive command.
+func driveVelocity(velocity int16, radius int16) int16 {
+	if 1 == radius || -1 == radius {
+		return 0 // turning in place
+	}
+	return velocity
+}
+
+func (o *OIBot) DriveStop() error {
+	return o.DriveStopContext(context.Background())
 }
 
-func (o *OIBot) DriveStop() {
-	o.Drive(0, 0)
+func (o *OIBot) DriveStopContext(ctx context.Context) error {
+	return o.DriveContext(WithPriority(ctx), 0, 0)
 }
 
-func (o *OIBot) DriveWheels(rightVelocity int16, leftVelocity int16) {
+func (o *OIBot) DriveWheels(rightVelocity int16, leftVelocity int16) error {
+	return o.DriveWheelsContext(context.Background(), rightVelocity, leftVelocity)
+}
+
+func (o *OIBot) DriveWheelsContext(ctx context.Context, rightVelocity int16, leftVelocity int16) error {
+	if err := validateDriveWheels(rightVelocity, leftVelocity); nil != err {
+		return err
+	}
+	if err := o.command(ctx, opcDriveWheels, rightVelocity, leftVelocity); nil != err {
+		return err
+	}
+	o.setVelocity((rightVelocity + leftVelocity) / 2)
+	return nil
+}
+
+func validateDriveWheels(rightVelocity int16, leftVelocity int16) error {
 	if rightVelocity < MinDriveVelocityMMPS || rightVelocity > MaxDriveVelocityMMPS {
-		o.errorLog.Panic(fmt.Errorf("invalid right wheel velocity: %d", rightVelocity))
+		return fmt.Errorf("%w: right wheel: %d", ErrInvalidVelocity, rightVelocity)
 	}
 	if leftVelocity < MinDriveVelocityMMPS || leftVelocity > MaxDriveVelocityMMPS {
-		o.errorLog.Panic(fmt.Errorf("invalid left wheel velocity: %d", leftVelocity))
+		return fmt.Errorf("%w: left wheel: %d", ErrInvalidVelocity, leftVelocity)
 	}
-	_ = o.Write(opcDriveWheels, rightVelocity, leftVelocity)
+	return nil
+}
+
+func (o *OIBot) setVelocity(velocity int16) {
+	o.mu.Lock()
+	o.velocity = velocity
+	o.mu.Unlock()
+}
+
+func (o *OIBot) commandedVelocity() int16 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.velocity
+}
+
+func (o *OIBot) Mode() (OpenInterfaceMode, error) {
+	return o.ModeContext(context.Background())
 }
 
-func (o *OIBot) Mode() OpenInterfaceMode {
-	data := o.Sensor(spcOpenInterfaceMode)
-	if len(data) > 0 {
-		return OpenInterfaceMode(data[0])
+func (o *OIBot) ModeContext(ctx context.Context) (OpenInterfaceMode, error) {
+	data, err := o.SensorContext(ctx, PacketOpenInterfaceMode)
+	if nil != err {
+		return OIMOff, err
 	}
-	return OIMOff
+	o.observeMode(OpenInterfaceMode(data[0]))
+	return OpenInterfaceMode(data[0]), nil
 }
 
 // =============================================================================
@@ -244,10 +642,10 @@ var (
 	// full general info status message
 	infoPacket = []*SensorPacket{
 		// OI mode
-		spcOpenInterfaceMode,
+		PacketOpenInterfaceMode,
 		// battery/charger
-		spcChargingState, spcVoltage, spcCurrent, spcBatteryCharge,
-		spcBatteryCapacity, spcChargerAvailable,
+		PacketChargingState, PacketVoltage, PacketCurrent, PacketBatteryCharge,
+		PacketBatteryCapacity, PacketChargerAvailable,
 	}
 
 	// battery-only status message
@@ -255,31 +653,35 @@ var (
 )
 
 type BatteryStatus struct {
-	ChargingState      byte
+	ChargingState      ChargingState
 	VoltagemV          uint16
 	CurrentmA          int16
 	BatteryChargemAh   uint16
 	BatteryCapacitymAh uint16
-	ChargerAvailable   byte
+	ChargerAvailable   ChargerAvailable
 }
 
 func batteryStatus(data [][]byte) *BatteryStatus {
 	return &BatteryStatus{
-		ChargingState:      data[0][0],
+		ChargingState:      ChargingState(data[0][0]),
 		VoltagemV:          uint16((uint16(data[1][0]) << 8) | uint16(data[1][1])),
 		CurrentmA:          int16((uint16(data[2][0]) << 8) | uint16(data[2][1])),
 		BatteryChargemAh:   uint16((uint16(data[3][0]) << 8) | uint16(data[3][1])),
 		BatteryCapacitymAh: uint16((uint16(data[4][0]) << 8) | uint16(data[4][1])),
-		ChargerAvailable:   data[5][0],
+		ChargerAvailable:   ChargerAvailable(data[5][0]),
 	}
 }
 
-func (o *OIBot) Battery() (*BatteryStatus, bool) {
-	data := o.SensorList(batteryPacket...)
-	if nil != data && len(data) == len(batteryPacket) {
-		return batteryStatus(data), true
+func (o *OIBot) Battery() (*BatteryStatus, error) {
+	return o.BatteryContext(context.Background())
+}
+
+func (o *OIBot) BatteryContext(ctx context.Context) (*BatteryStatus, error) {
+	data, err := o.SensorListContext(ctx, batteryPacket...)
+	if nil != err {
+		return nil, err
 	}
-	return nil, false
+	return batteryStatus(data), nil
 }
 
 type InfoStatus struct {
@@ -287,13 +689,18 @@ type InfoStatus struct {
 	Battery *BatteryStatus
 }
 
-func (o *OIBot) Info() (*InfoStatus, bool) {
-	data := o.SensorList(infoPacket...)
-	if nil != data && len(data) == len(infoPacket) {
-		return &InfoStatus{
-			Mode:    OpenInterfaceMode(data[0][0]),
-			Battery: batteryStatus(data[1:]),
-		}, true
+func (o *OIBot) Info() (*InfoStatus, error) {
+	return o.InfoContext(context.Background())
+}
+
+func (o *OIBot) InfoContext(ctx context.Context) (*InfoStatus, error) {
+	data, err := o.SensorListContext(ctx, infoPacket...)
+	if nil != err {
+		return nil, err
 	}
-	return nil, false
+	o.observeMode(OpenInterfaceMode(data[0][0]))
+	return &InfoStatus{
+		Mode:    OpenInterfaceMode(data[0][0]),
+		Battery: batteryStatus(data[1:]),
+	}, nil
 }
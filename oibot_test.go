@@ -0,0 +1,74 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+)
+
+// newPipeBot returns an OIBot on one end of an in-memory pipe, and the other
+// end standing in for the robot along with the bytes it receives. The Start
+// that puts the robot in Passive mode has already been received.
+func newPipeBot(t *testing.T) (*OIBot, net.Conn, <-chan byte) {
+	t.Helper()
+	host, robot := net.Pipe()
+	t.Cleanup(func() { robot.Close() })
+	rx := make(chan byte, 256)
+	go func() {
+		defer close(rx)
+		buf := make([]byte, 64)
+		for {
+			n, err := robot.Read(buf)
+			for _, b := range buf[:n] {
+				rx <- b
+			}
+			if nil != err {
+				return
+			}
+		}
+	}()
+	l := log.New(io.Discard, "", 0)
+	o, err := MakeOIBotTransport(l, l, false, WrapTransport(host), DefaultBaudRateBPS, NeverReadTimeoutMS)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if code := <-rx; byte(opcStart) != code {
+		t.Fatalf("robot received %d; want Start", code)
+	}
+	return o, robot, rx
+}
+
+func TestSensorContext(t *testing.T) {
+	o, robot, rx := newPipeBot(t)
+	go func() {
+		if byte(opcQuery) == <-rx && PacketOpenInterfaceMode.id == <-rx {
+			robot.Write([]byte{byte(OIMSafe)})
+		}
+	}()
+	data, err := o.Sensor(PacketOpenInterfaceMode)
+	if nil != err || 1 != len(data) || byte(OIMSafe) != data[0] {
+		t.Fatalf("Sensor() = %v, %v", data, err)
+	}
+}
+
+func TestSensorContextCancel(t *testing.T) {
+	o, _, _ := newPipeBot(t) // a robot that never answers
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := o.SensorContext(ctx, PacketOpenInterfaceMode); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SensorContext() = %v; want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("SensorContext() took %s to notice the deadline", elapsed)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := o.StartContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("StartContext() = %v; want context.Canceled", err)
+	}
+}
@@ -0,0 +1,86 @@
+package oibot
+
+import (
+	"context"
+	"time"
+)
+
+// bitsPerByte is the cost of one byte on the line at 8N1: a start bit, eight
+// data bits and a stop bit.
+const bitsPerByte = 10
+
+// Pacing decides how long the line is held after each command: the time the
+// command's bytes take to go out at the current baud rate, plus a guard time
+// for the robot to act on it.
+type Pacing struct {
+	Guard     time.Duration // after any command
+	ModeGuard time.Duration // after a command that changes the OI mode
+}
+
+var (
+	DefaultPacing = Pacing{Guard: time.Millisecond, ModeGuard: 20 * time.Millisecond}
+
+	// FixedPacing reproduces the fixed SerialTransferDelayMS spacing of earlier
+	// releases, for robots or bridges that need the extra slack.
+	FixedPacing = Pacing{Guard: SerialTransferDelayMS, ModeGuard: SerialTransferDelayMS}
+)
+
+// PacedTransport is implemented by transports that need commands spaced
+// other than by DefaultPacing, such as a network bridge with its own
+// buffering. A pacing set with SetPacing takes precedence.
+type PacedTransport interface {
+	Transport
+	Pacing() Pacing
+}
+
+// Spacing returns how long after writing n bytes holding the given commands the
+// next command may be sent at baud. Each command adds its guard time.
+func (p Pacing) Spacing(baud int, n int, code ...OpCode) time.Duration {
+	d := time.Duration(n*bitsPerByte) * time.Second / time.Duration(baud)
+	for _, c := range code {
+		if _, ok := nextMode[c]; ok {
+			d += p.ModeGuard
+		} else {
+			d += p.Guard
+		}
+	}
+	return d
+}
+
+// SetPacing overrides the transport's pacing for every command sent after it.
+func (o *OIBot) SetPacing(p Pacing) {
+	o.mu.Lock()
+	o.pacing, o.paced = p, true
+	o.mu.Unlock()
+}
+
+func (o *OIBot) Pacing() Pacing {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.paced {
+		return o.pacing
+	}
+	if t, ok := o.port.(PacedTransport); ok {
+		return t.Pacing()
+	}
+	return DefaultPacing
+}
+
+// =============================================================================
+
+// pacer holds back each command until the one before it has cleared the line.
+// It is only touched from queued jobs, which never run concurrently.
+type pacer struct {
+	next time.Time
+}
+
+func (p *pacer) wait(ctx context.Context) error {
+	if d := time.Until(p.next); d > 0 {
+		return sleepContext(ctx, d)
+	}
+	return nil
+}
+
+func (p *pacer) sent(spacing time.Duration) {
+	p.next = time.Now().Add(spacing)
+}
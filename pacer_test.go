@@ -0,0 +1,48 @@
+package oibot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacingSpacing(t *testing.T) {
+	for _, test := range []struct {
+		baud     int
+		n        int
+		code     OpCode
+		min, max time.Duration
+	}{
+		{115200, 5, opcDrive, time.Millisecond, 2 * time.Millisecond},
+		{19200, 5, opcDrive, 3 * time.Millisecond, 4 * time.Millisecond},
+		{115200, 1, opcSafe, DefaultPacing.ModeGuard, DefaultPacing.ModeGuard + time.Millisecond},
+		{115200, 1, opcFull, DefaultPacing.ModeGuard, DefaultPacing.ModeGuard + time.Millisecond},
+	} {
+		if s := DefaultPacing.Spacing(test.baud, test.n, test.code); s < test.min || s > test.max {
+			t.Errorf("Spacing(%d, %d, %d) = %s; want %s to %s", test.baud, test.n, test.code, s, test.min, test.max)
+		}
+	}
+}
+
+func TestPacingRate(t *testing.T) {
+	o, _ := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i := 0; i < 60; i++ {
+		if err := o.DriveWheels(100, 100); nil != err {
+			t.Fatal(err)
+		}
+		if _, err := o.SensorList(PacketEncoderCountsLeft, PacketEncoderCountsRight); nil != err {
+			t.Fatal(err)
+		}
+	}
+	// the fixed 50 ms sleeps this replaced would take 6 s
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("60 commands and queries took %s", elapsed)
+	}
+	o.SetPacing(FixedPacing)
+	if FixedPacing != o.Pacing() {
+		t.Fatalf("Pacing() = %+v after SetPacing(FixedPacing)", o.Pacing())
+	}
+}
@@ -0,0 +1,120 @@
+package oibot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	sensorGroups = []*SensorGroup{
+		GroupStatus, GroupObstacle, GroupDock, GroupBattery, GroupSignal,
+		GroupModeData, GroupSensor, GroupAll, GroupDrive, GroupProximity,
+		GroupActuator,
+	}
+	packetByID   = map[byte]*SensorPacket{}
+	packetByName = map[string]*SensorPacket{}
+	groupByID    = map[byte]*SensorGroup{}
+	groupByName  = map[string]*SensorGroup{}
+)
+
+func init() {
+	for _, p := range GroupAll.member {
+		packetByID[p.id] = p
+		packetByName[registryKey(p.name)] = p
+	}
+	for _, g := range sensorGroups {
+		groupByID[g.id] = g
+		groupByName[registryKey(g.name)] = g
+	}
+}
+
+// registryKey folds case and drops everything but letters and digits, so that
+// "Song Playing?", "song playing" and "SongPlaying" all name the same packet.
+func registryKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// =============================================================================
+
+// SensorPackets returns every sensor packet, ordered by ID.
+func SensorPackets() []*SensorPacket {
+	return append([]*SensorPacket(nil), GroupAll.member...)
+}
+
+func PacketByID(id byte) (*SensorPacket, bool) {
+	p, ok := packetByID[id]
+	return p, ok
+}
+
+func PacketByName(name string) (*SensorPacket, bool) {
+	p, ok := packetByName[registryKey(name)]
+	return p, ok
+}
+
+func (p *SensorPacket) ID() byte       { return p.id }
+func (p *SensorPacket) Size() byte     { return p.size }
+func (p *SensorPacket) Name() string   { return p.name }
+func (p *SensorPacket) Signed() bool   { return p.signed }
+func (p *SensorPacket) Units() string  { return p.units }
+func (p *SensorPacket) String() string { return fmt.Sprintf("%s (%d)", p.name, p.id) }
+
+func (p *SensorPacket) Range() (min int32, max int32) {
+	return p.min, p.max
+}
+
+// Value interprets a raw response according to the packet's size and
+// signedness.
+func (p *SensorPacket) Value(data []byte) (int32, error) {
+	if len(data) != int(p.size) {
+		return 0, fmt.Errorf("sensor packet %d: expected %d bytes, got %d", p.id, p.size, len(data))
+	}
+	switch {
+	case 1 == p.size && p.signed:
+		return int32(int8(data[0])), nil
+	case 1 == p.size:
+		return int32(data[0]), nil
+	case p.signed:
+		return int32(int16(binary.BigEndian.Uint16(data))), nil
+	default:
+		return int32(binary.BigEndian.Uint16(data)), nil
+	}
+}
+
+func (p *SensorPacket) Validate(value int32) error {
+	if value < p.min || value > p.max {
+		return fmt.Errorf("%w: %s: %d not in [%d, %d]", ErrOutOfRange, p.name, value, p.min, p.max)
+	}
+	return nil
+}
+
+// =============================================================================
+
+func SensorGroups() []*SensorGroup {
+	return append([]*SensorGroup(nil), sensorGroups...)
+}
+
+func GroupByID(id byte) (*SensorGroup, bool) {
+	g, ok := groupByID[id]
+	return g, ok
+}
+
+func GroupByName(name string) (*SensorGroup, bool) {
+	g, ok := groupByName[registryKey(name)]
+	return g, ok
+}
+
+func (g *SensorGroup) ID() byte       { return g.id }
+func (g *SensorGroup) Size() byte     { return g.size }
+func (g *SensorGroup) Name() string   { return g.name }
+func (g *SensorGroup) String() string { return fmt.Sprintf("%s (%d)", g.name, g.id) }
+
+func (g *SensorGroup) Members() []*SensorPacket {
+	return append([]*SensorPacket(nil), g.member...)
+}
@@ -0,0 +1,65 @@
+package oibot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPacketByName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want *SensorPacket
+	}{
+		{"Song Playing?", PacketSongPlaying},
+		{"song playing", PacketSongPlaying},
+		{"SongPlaying", PacketSongPlaying},
+		{"open interface mode", PacketOpenInterfaceMode},
+		{"Stasis", PacketStasis},
+		{"Flux Capacitor", nil},
+	} {
+		p, ok := PacketByName(test.name)
+		if test.want != p || (nil != test.want) != ok {
+			t.Errorf("PacketByName(%q) = %v, %t; want %v", test.name, p, ok, test.want)
+		}
+	}
+	if 52 != len(SensorPackets()) {
+		t.Errorf("%d sensor packets; want 52", len(SensorPackets()))
+	}
+	for _, p := range SensorPackets() {
+		if q, ok := PacketByID(p.ID()); !ok || p != q {
+			t.Errorf("PacketByID(%d) = %v", p.ID(), q)
+		}
+	}
+}
+
+func TestPacketValue(t *testing.T) {
+	for _, test := range []struct {
+		packet *SensorPacket
+		data   []byte
+		want   int32
+		valid  bool
+	}{
+		{PacketWall, []byte{1}, 1, true},
+		{PacketWall, []byte{2}, 2, false},
+		{PacketTemperature, []byte{0xe2}, -30, true},
+		{PacketAngle, []byte{0xff, 0xfe}, -2, true},
+		{PacketVoltage, []byte{0xff, 0xfe}, 65534, true},
+		{PacketVelocity, []byte{0x01, 0xf4}, 500, true},
+		{PacketVelocity, []byte{0x01, 0xf5}, 501, false},
+		{PacketOpenInterfaceMode, []byte{4}, 4, false},
+	} {
+		v, err := test.packet.Value(test.data)
+		if nil != err || test.want != v {
+			t.Errorf("%s: Value(%v) = %d, %v; want %d", test.packet, test.data, v, err, test.want)
+			continue
+		}
+		if err := test.packet.Validate(v); test.valid != (nil == err) {
+			t.Errorf("%s: Validate(%d) = %v", test.packet, v, err)
+		} else if nil != err && !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%s: Validate(%d) = %v; want ErrOutOfRange", test.packet, v, err)
+		}
+	}
+	if _, err := PacketAngle.Value([]byte{1}); nil == err {
+		t.Error("Value() accepted a short response")
+	}
+}
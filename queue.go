@@ -0,0 +1,188 @@
+package oibot
+
+import (
+	"context"
+	"sync"
+)
+
+// Every exchange with the robot runs as a job on a single writer goroutine, so
+// the bytes of a command, or of a query and its reply, are never interleaved
+// with those of another caller. Priority jobs are taken before any ordinary
+// job still waiting. A job must not submit another job: it would wait forever
+// behind itself.
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose commands jump ahead of ordinary
+// commands waiting in the queue. DriveStop, MotorsOff and the safety
+// supervisor use it; a command already being sent is never interrupted.
+func WithPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+func isPriority(ctx context.Context) bool {
+	p, _ := ctx.Value(priorityKey{}).(bool)
+	return p
+}
+
+type QueueStats struct {
+	Depth         int // jobs waiting to be sent, including priority jobs
+	PriorityDepth int
+	MaxDepth      int // the largest Depth seen
+	Submitted     uint64
+	Completed     uint64
+	Canceled      uint64 // abandoned by their caller before being sent
+}
+
+type job struct {
+	ctx     context.Context
+	fn      func(context.Context) error
+	done    chan error
+	started bool
+}
+
+type commandQueue struct {
+	mu       sync.Mutex
+	normal   []*job
+	priority []*job
+	stats    QueueStats
+	closed   bool
+	ready    chan struct{}
+	quit     chan struct{}
+	stopped  chan struct{}
+}
+
+func newCommandQueue() *commandQueue {
+	q := &commandQueue{
+		ready:   make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.serve()
+	return q
+}
+
+// do runs fn on the writer goroutine and returns its error. If ctx is done
+// before fn starts, fn is dropped from the queue; once started, fn is left to
+// honor ctx itself.
+func (q *commandQueue) do(ctx context.Context, fn func(context.Context) error) error {
+	if err := ctx.Err(); nil != err {
+		return err
+	}
+	j := &job{ctx: ctx, fn: fn, done: make(chan error, 1)}
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrPortClosed
+	}
+	if isPriority(ctx) {
+		q.priority = append(q.priority, j)
+	} else {
+		q.normal = append(q.normal, j)
+	}
+	q.stats.Submitted++
+	if depth := len(q.normal) + len(q.priority); depth > q.stats.MaxDepth {
+		q.stats.MaxDepth = depth
+	}
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+		q.mu.Lock()
+		if !j.started && q.remove(j) {
+			q.stats.Canceled++
+			q.mu.Unlock()
+			return ctx.Err()
+		}
+		q.mu.Unlock()
+		return <-j.done
+	}
+}
+
+func (q *commandQueue) serve() {
+	defer close(q.stopped)
+	for {
+		j := q.next()
+		if nil == j {
+			select {
+			case <-q.ready:
+				continue
+			case <-q.quit:
+				return
+			}
+		}
+		err := j.fn(j.ctx)
+		q.mu.Lock()
+		q.stats.Completed++
+		q.mu.Unlock()
+		j.done <- err
+	}
+}
+
+func (q *commandQueue) next() *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var j *job
+	switch {
+	case len(q.priority) > 0:
+		j, q.priority = q.priority[0], q.priority[1:]
+	case len(q.normal) > 0:
+		j, q.normal = q.normal[0], q.normal[1:]
+	default:
+		return nil
+	}
+	j.started = true
+	return j
+}
+
+func (q *commandQueue) remove(j *job) bool {
+	for _, list := range []*[]*job{&q.priority, &q.normal} {
+		for i, k := range *list {
+			if k == j {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// close fails every waiting job with ErrPortClosed and stops the writer once
+// the job in progress, if any, returns.
+func (q *commandQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	pending := append(q.priority, q.normal...)
+	q.priority, q.normal = nil, nil
+	q.mu.Unlock()
+	for _, j := range pending {
+		j.done <- ErrPortClosed
+	}
+	close(q.quit)
+	<-q.stopped
+}
+
+func (q *commandQueue) statistics() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Depth = len(q.normal) + len(q.priority)
+	s.PriorityDepth = len(q.priority)
+	return s
+}
+
+// =============================================================================
+
+// QueueStats reports the depth and throughput of the command queue.
+func (o *OIBot) QueueStats() QueueStats {
+	return o.queue.statistics()
+}
@@ -0,0 +1,87 @@
+package oibot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hold occupies the writer until release is closed.
+func hold(o *OIBot) (release chan struct{}) {
+	release = make(chan struct{})
+	running := make(chan struct{})
+	go o.queue.do(context.Background(), func(context.Context) error {
+		close(running)
+		<-release
+		return nil
+	})
+	<-running
+	return release
+}
+
+// waitDepth waits for the queue to hold depth jobs.
+func waitDepth(t *testing.T, o *OIBot, depth int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if o.QueueStats().Depth == depth {
+			return
+		}
+	}
+	t.Fatalf("queue depth %d; want %d", o.QueueStats().Depth, depth)
+}
+
+func TestQueuePriority(t *testing.T) {
+	o, _ := newSimBot(t, nil)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var order []string
+	submit := func(ctx context.Context, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.queue.do(ctx, func(context.Context) error {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	release := hold(o)
+	submit(context.Background(), "first")
+	waitDepth(t, o, 1)
+	submit(context.Background(), "second")
+	waitDepth(t, o, 2)
+	submit(WithPriority(context.Background()), "stop")
+	waitDepth(t, o, 3)
+	if s := o.QueueStats(); 1 != s.PriorityDepth {
+		t.Fatalf("PriorityDepth = %d; want 1", s.PriorityDepth)
+	}
+	close(release)
+	wg.Wait()
+	if 3 != len(order) || "stop" != order[0] || "first" != order[1] || "second" != order[2] {
+		t.Fatalf("jobs ran in order %v", order)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	o, _ := newSimBot(t, nil)
+	release := hold(o)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := o.ModeContext(ctx); context.DeadlineExceeded != err {
+		t.Fatalf("ModeContext() behind a busy writer = %v", err)
+	}
+	close(release)
+	if s := o.QueueStats(); 1 != s.Canceled || 0 != s.Depth {
+		t.Fatalf("QueueStats() = %+v", s)
+	}
+	if _, err := o.Mode(); nil != err {
+		t.Fatal(err)
+	}
+	o.Close()
+	if err := o.Safe(); ErrPortClosed != err {
+		t.Fatalf("Safe() after Close = %v", err)
+	}
+}
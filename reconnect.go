@@ -0,0 +1,397 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type ConnectionState byte
+
+const (
+	Disconnected ConnectionState = iota
+	Reconnected
+	ReconnectFailed // the Reconnector gave up
+)
+
+var connectionStateStr = [...]string{"DISCONNECTED", "RECONNECTED", "RECONNECT FAILED"}
+
+func (s ConnectionState) String() string {
+	if int(s) < len(connectionStateStr) {
+		return connectionStateStr[s]
+	}
+	return fmt.Sprintf("STATE(%d)", byte(s))
+}
+
+// ConnectionEvent reports a change in the state of the link. Attempts and
+// Downtime are set once the outage is over; Err holds the failure that caused
+// it, or the last error before giving up.
+type ConnectionEvent struct {
+	Time     time.Time
+	State    ConnectionState
+	Attempts int
+	Downtime time.Duration
+	Err      error
+}
+
+type ReconnectConfig struct {
+	// Dial opens a new transport. If nil, the serial device the OIBot was
+	// made with is reopened at the current baud rate and read timeout.
+	Dial        func() (Transport, error)
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	MaxAttempts int // zero retries forever
+}
+
+var DefaultReconnectConfig = ReconnectConfig{
+	MinBackoff: 100 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+const reconnectSubscriberBuffer = 16
+
+// =============================================================================
+
+// Reconnector supervises the link to the robot. When a read or write fails
+// with an I/O error it reopens the transport with exponential backoff, sends
+// Start, restores the OI mode the robot was last in and restarts any active
+// sensor stream, notifying subscribers as the link goes down and comes back.
+// Until then every command fails with ErrDisconnected.
+type Reconnector struct {
+	o      *OIBot
+	config ReconnectConfig
+	mu     sync.Mutex
+	subs   map[chan ConnectionEvent]struct{}
+	faults chan error
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func (o *OIBot) NewReconnector(config ReconnectConfig) *Reconnector {
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultReconnectConfig.MinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = DefaultReconnectConfig.MaxBackoff
+		if config.MaxBackoff < config.MinBackoff {
+			config.MaxBackoff = config.MinBackoff
+		}
+	}
+	if nil == config.Dial && "" != o.path {
+		config.Dial = func() (Transport, error) {
+			return OpenSerialTransport(o.path, o.BaudRate(), o.timeout)
+		}
+	}
+	return &Reconnector{o: o, config: config, subs: map[chan ConnectionEvent]struct{}{}}
+}
+
+// Start supervises the link until Stop is called or ctx is done. Only one
+// Reconnector may supervise an OIBot at a time.
+func (r *Reconnector) Start(ctx context.Context) error {
+	if nil == r.config.Dial {
+		return errors.New("reconnector has no way to reopen the transport")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if nil != r.cancel {
+		return errors.New("reconnector already running")
+	}
+	faults := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case faults <- err:
+		default:
+		}
+	}
+	if err := r.o.supervise(report); nil != err {
+		return err
+	}
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.faults, r.done, r.err = faults, make(chan struct{}), nil
+	go r.run(ctx, r.done)
+	return nil
+}
+
+func (r *Reconnector) Stop() error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+	if nil != cancel {
+		cancel()
+		<-done
+	}
+	return r.Err()
+}
+
+// Err returns the error that ended supervision, if any.
+func (r *Reconnector) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Reconnector) Subscribe() <-chan ConnectionEvent {
+	ch := make(chan ConnectionEvent, reconnectSubscriberBuffer)
+	r.mu.Lock()
+	r.subs[ch] = struct{}{}
+	r.mu.Unlock()
+	return ch
+}
+
+func (r *Reconnector) Unsubscribe(ch <-chan ConnectionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.subs {
+		if c == ch {
+			delete(r.subs, c)
+			close(c)
+		}
+	}
+}
+
+// emit delivers ev to every subscriber with room for it; a slow subscriber
+// misses events rather than stalling recovery.
+func (r *Reconnector) emit(ev ConnectionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.subs {
+		select {
+		case c <- ev:
+		default:
+		}
+	}
+}
+
+func (r *Reconnector) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	defer r.o.unsupervise()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cause := <-r.faults:
+			if err := r.reconnect(ctx, cause); nil != err {
+				if nil == ctx.Err() {
+					r.mu.Lock()
+					r.err = err
+					r.mu.Unlock()
+				}
+				return
+			}
+		}
+	}
+}
+
+func (r *Reconnector) reconnect(ctx context.Context, cause error) error {
+	start := time.Now()
+	mode, known := r.o.TrackedMode()
+	stream := r.o.activeStream()
+	r.emit(ConnectionEvent{Time: start, State: Disconnected, Err: cause})
+	r.o.transport().Close()
+	backoff := r.config.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := r.attempt(ctx, mode, known, stream)
+		if nil == err {
+			r.o.recovered()
+			select {
+			case <-r.faults: // raised by the attempts that failed
+			default:
+			}
+			now := time.Now()
+			r.emit(ConnectionEvent{Time: now, State: Reconnected, Attempts: attempt, Downtime: now.Sub(start)})
+			return nil
+		}
+		if nil != ctx.Err() {
+			return ctx.Err()
+		}
+		// a closed OIBot will never come back
+		if closed := r.o.isClosed(); closed || (r.config.MaxAttempts > 0 && attempt >= r.config.MaxAttempts) {
+			if closed {
+				err = ErrPortClosed
+			} else {
+				err = fmt.Errorf("%w: gave up after %d attempts: %w", ErrDisconnected, attempt, err)
+			}
+			r.emit(ConnectionEvent{Time: time.Now(), State: ReconnectFailed, Attempts: attempt, Downtime: time.Since(start), Err: err})
+			return err
+		}
+		if err := sleepContext(ctx, backoff); nil != err {
+			return err
+		}
+		if backoff *= 2; backoff > r.config.MaxBackoff {
+			backoff = r.config.MaxBackoff
+		}
+	}
+}
+
+// attempt opens a new transport and brings the robot back to where it was.
+// On failure the new transport is closed and the link left down.
+func (r *Reconnector) attempt(ctx context.Context, mode OpenInterfaceMode, known bool, stream *SensorStream) error {
+	if r.o.isClosed() {
+		return ErrPortClosed
+	}
+	port, err := r.config.Dial()
+	if nil != err {
+		return err
+	}
+	if err := r.o.replaceTransport(ctx, port); nil != err {
+		port.Close()
+		return err
+	}
+	if err := r.restore(ctx, mode, known, stream); nil != err {
+		r.o.linkDown(err)
+		port.Close()
+		return err
+	}
+	return nil
+}
+
+// restore finds the robot on the new transport and returns it to mode. Once
+// the stream is restarted queries are impossible, so with a stream active a
+// change of mode is not verified; the stream's own mode packet, if any, will
+// report a mismatch.
+func (r *Reconnector) restore(ctx context.Context, mode OpenInterfaceMode, known bool, stream *SensorStream) error {
+	if err := r.o.relink(ctx, nil != stream); nil != err {
+		return err
+	}
+	var err error
+	switch current, _ := r.o.TrackedMode(); {
+	case !known || current == mode:
+	case OIMOff == mode:
+		err = r.o.StopContext(ctx)
+	case OIMPassive == mode:
+		err = r.o.StartContext(ctx)
+	case nil != stream:
+		code := opcSafe
+		if OIMFull == mode {
+			code = opcFull
+		}
+		err = r.o.command(ctx, code)
+	default:
+		err = r.o.SetModeContext(ctx, mode)
+	}
+	if nil != err {
+		return err
+	}
+	if nil != stream {
+		return stream.restart(ctx)
+	}
+	return nil
+}
+
+// =============================================================================
+
+func (o *OIBot) supervise(report func(error)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if nil != o.fault {
+		return errors.New("link already supervised")
+	}
+	o.fault = report
+	return nil
+}
+
+// unsupervise ends supervision. An outage in progress stays in effect, but
+// anything waiting for it to end is released.
+func (o *OIBot) unsupervise() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fault = nil
+	if nil != o.linkUp {
+		close(o.linkUp)
+		o.linkUp = nil
+	}
+}
+
+// linkDown records a failure of the link itself, as opposed to a timeout or a
+// refused command, if a Reconnector is supervising it.
+func (o *OIBot) linkDown(err error) {
+	o.mu.Lock()
+	fault := o.fault
+	if nil == fault || o.closed {
+		o.mu.Unlock()
+		return
+	}
+	if nil == o.linkErr {
+		o.linkErr = err
+	}
+	if nil == o.linkUp {
+		o.linkUp = make(chan struct{})
+	}
+	o.mu.Unlock()
+	fault(err)
+}
+
+func (o *OIBot) linkError() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if nil != o.linkErr {
+		return fmt.Errorf("%w: %w", ErrDisconnected, o.linkErr)
+	}
+	return nil
+}
+
+// outage returns a channel closed when the current outage is over, or nil if
+// the link is up or unsupervised.
+func (o *OIBot) outage() <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.linkUp
+}
+
+func (o *OIBot) recovered() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if nil != o.linkUp {
+		close(o.linkUp)
+		o.linkUp = nil
+	}
+}
+
+// relink confirms that the robot answers on a newly opened transport, at the
+// rate the link was at. A robot that only lost the link answers at once, but
+// one that was power cycled has fallen back to its default rate in Off mode,
+// and is found, started and moved back to the link's rate. A robot that may
+// still be streaming is paused first so that its frames are not mistaken for
+// the reply.
+func (o *OIBot) relink(ctx context.Context, streaming bool) error {
+	var mode OpenInterfaceMode
+	err := o.queue.do(ctx, func(ctx context.Context) error {
+		if streaming {
+			if _, err := o.write(ctx, opcDoStream, []byte{streamStatePause}); nil != err {
+				return err
+			}
+			if err := sleepContext(ctx, SensorUpdateDelayMS); nil != err {
+				return err
+			}
+		}
+		var err error
+		mode, err = o.negotiate(ctx, o.BaudRate())
+		return err
+	})
+	if nil != err {
+		return err
+	}
+	o.observeMode(mode)
+	return nil
+}
+
+// replaceTransport swaps in port between jobs and lets commands through again.
+func (o *OIBot) replaceTransport(ctx context.Context, port Transport) error {
+	return o.queue.do(ctx, func(context.Context) error {
+		o.mu.Lock()
+		o.port, o.linkErr = port, nil
+		o.mu.Unlock()
+		o.pacer = pacer{}
+		return nil
+	})
+}
+
+func (o *OIBot) activeStream() *SensorStream {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stream
+}
@@ -0,0 +1,232 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// expectState waits for the next event on ch and checks its state.
+func expectState(t *testing.T, ch <-chan ConnectionEvent, want ConnectionState) ConnectionEvent {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		if want != ev.State {
+			t.Fatalf("event %s (%v); want %s", ev.State, ev.Err, want)
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s event", want)
+	}
+	return ConnectionEvent{}
+}
+
+func TestReconnect(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	fails := 2
+	r := o.NewReconnector(ReconnectConfig{
+		Dial: func() (Transport, error) {
+			if fails > 0 {
+				fails--
+				return nil, errors.New("no device")
+			}
+			return sim.Transport(), nil
+		},
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	s, err := o.Stream(PacketOpenInterfaceMode, PacketEncoderCountsLeft)
+	if nil != err {
+		t.Fatal(err)
+	}
+	<-s.C
+	sim.Unplug()
+	expectState(t, ch, Disconnected)
+	if ev := expectState(t, ch, Reconnected); 3 != ev.Attempts {
+		t.Fatalf("reconnected after %d attempts; want 3", ev.Attempts)
+	}
+	// the stream carries on, on the same channel, once frames from before
+	// the outage are drained
+	for drain := time.After(100 * time.Millisecond); nil != drain; {
+		select {
+		case <-s.C:
+		case <-drain:
+			drain = nil
+		}
+	}
+	select {
+	case f, ok := <-s.C:
+		if !ok {
+			t.Fatalf("stream ended: %v", s.Err())
+		}
+		if mode, _ := f.Get(PacketOpenInterfaceMode); byte(OIMSafe) != mode[0] {
+			t.Fatalf("robot streaming from mode %d; want %s", mode[0], OIMSafe)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream did not resume")
+	}
+	if err := s.Stop(); nil != err {
+		t.Fatal(err)
+	}
+	if err := o.Drive(50, StraightDriveRadiusMM); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestReconnectStreamStopped(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	plug := make(chan struct{})
+	r := o.NewReconnector(ReconnectConfig{
+		Dial: func() (Transport, error) {
+			<-plug
+			return sim.Transport(), nil
+		},
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	s, err := o.Stream(PacketOpenInterfaceMode)
+	if nil != err {
+		t.Fatal(err)
+	}
+	<-s.C
+	sim.Unplug()
+	expectState(t, ch, Disconnected)
+	s.Stop()
+	close(plug)
+	expectState(t, ch, Reconnected)
+	if _, err := o.Mode(); nil != err {
+		t.Fatal(err)
+	}
+	sim.mu.Lock()
+	streaming := sim.streaming
+	sim.mu.Unlock()
+	if streaming {
+		t.Fatal("stream stopped during the outage was restarted")
+	}
+}
+
+// hangup accepts every write and reads end of file, like a port whose device
+// went away.
+type hangup struct{}
+
+func (hangup) Read([]byte) (int, error)    { return 0, io.EOF }
+func (hangup) Write(b []byte) (int, error) { return len(b), nil }
+func (hangup) Close() error                { return nil }
+
+func TestReconnectHangup(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	dials := 0
+	r := o.NewReconnector(ReconnectConfig{
+		Dial: func() (Transport, error) {
+			if dials++; 1 == dials {
+				return WrapTransport(hangup{}), nil
+			}
+			return sim.Transport(), nil
+		},
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	sim.Unplug()
+	if _, err := o.Mode(); !errors.Is(err, ErrDisconnected) {
+		t.Fatalf("Mode() on an unplugged port = %v", err)
+	}
+	expectState(t, ch, Disconnected)
+	if ev := expectState(t, ch, Reconnected); 2 != ev.Attempts {
+		t.Fatalf("reconnected after %d attempts; want 2", ev.Attempts)
+	}
+}
+
+func TestReconnectPowerCycle(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.NegotiateBaud(57600); nil != err {
+		t.Fatal(err)
+	}
+	if err := o.SetMode(OIMSafe); nil != err {
+		t.Fatal(err)
+	}
+	r := o.NewReconnector(ReconnectConfig{
+		Dial:       func() (Transport, error) { return sim.Transport(), nil },
+		MinBackoff: 20 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	// the robot resets, back to its default rate in Off mode, while the
+	// cable is out
+	sim.Transport().Write([]byte{byte(opcReset)})
+	time.Sleep(2 * SensorUpdateDelayMS)
+	sim.Unplug()
+	o.Mode()
+	expectState(t, ch, Disconnected)
+	expectState(t, ch, Reconnected)
+	if mode, err := o.Mode(); nil != err || OIMSafe != mode || 57600 != o.BaudRate() {
+		t.Fatalf("Mode() = %s, %v at %d baud; want %s at 57600", mode, err, o.BaudRate(), OIMSafe)
+	}
+}
+
+func TestReconnectGiveUp(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	gone := errors.New("gone")
+	r := o.NewReconnector(ReconnectConfig{
+		Dial:        func() (Transport, error) { return nil, gone },
+		MinBackoff:  10 * time.Millisecond,
+		MaxAttempts: 2,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	sim.Unplug()
+	o.Mode()
+	expectState(t, ch, Disconnected)
+	if ev := expectState(t, ch, ReconnectFailed); 2 != ev.Attempts {
+		t.Fatalf("gave up after %d attempts; want 2", ev.Attempts)
+	}
+	if err := r.Stop(); !errors.Is(err, ErrDisconnected) || !errors.Is(err, gone) {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if _, err := o.Mode(); !errors.Is(err, ErrDisconnected) {
+		t.Fatalf("Mode() with the link down = %v", err)
+	}
+}
+
+func TestReconnectClosed(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	r := o.NewReconnector(ReconnectConfig{
+		Dial:       func() (Transport, error) { return nil, errors.New("gone") },
+		MinBackoff: 10 * time.Millisecond,
+	})
+	ch := r.Subscribe()
+	if err := r.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	sim.Unplug()
+	o.Mode()
+	expectState(t, ch, Disconnected)
+	o.Close()
+	if ev := expectState(t, ch, ReconnectFailed); !errors.Is(ev.Err, ErrPortClosed) {
+		t.Fatalf("gave up with %v; want ErrPortClosed", ev.Err)
+	}
+	if err := r.Stop(); !errors.Is(err, ErrPortClosed) {
+		t.Fatalf("Stop() = %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SafetyRule uint
+
+const (
+	SafetyBump SafetyRule = 1 << iota
+	SafetyWheelDrop
+	SafetyCliff
+	SafetyOvercurrent
+	SafetyStasis
+
+	SafetyAll = SafetyBump | SafetyWheelDrop | SafetyCliff | SafetyOvercurrent | SafetyStasis
+)
+
+var safetyRuleStr = [...]string{"BUMP", "WHEELDROP", "CLIFF", "OVERCURRENT", "STASIS"}
+
+func (r SafetyRule) String() string {
+	var name []string
+	for i, s := range safetyRuleStr {
+		if 0 != r&(1<<uint(i)) {
+			name = append(name, s)
+		}
+	}
+	if 0 == len(name) {
+		return "NONE"
+	}
+	return strings.Join(name, "|")
+}
+
+type SafetyConfig struct {
+	Rules  SafetyRule
+	Period time.Duration
+	// StasisSamples is how many consecutive samples the caster must be
+	// stationary while the robot is commanded forward before SafetyStasis
+	// trips.
+	StasisSamples int
+}
+
+var DefaultSafetyConfig = SafetyConfig{
+	Rules:         SafetyAll,
+	Period:        SensorUpdateDelayMS,
+	StasisSamples: 10,
+}
+
+// SafetyEvent reports the rules that tripped, the sensor readings that tripped
+// them, and any error encountered while stopping the robot in response. An
+// event with no Rule reports, in Err, rules that cannot currently be checked
+// or a poll of the sensors that failed.
+type SafetyEvent struct {
+	Time time.Time
+	Rule SafetyRule
+	Data SensorData
+	Err  error
+}
+
+var safetyPacket = []*SensorPacket{
+	PacketBumpsWheeldrops, PacketCliffLeft, PacketCliffFrontLeft,
+	PacketCliffFrontRight, PacketCliffRight, PacketOvercurrents, PacketStasis,
+}
+
+const safetySubscriberBuffer = 16
+
+// =============================================================================
+
+// SafetySupervisor watches the hazard sensors of an OIBot and, when one of its
+// rules trips, stops the wheels and cleaning motors and notifies subscribers.
+// A rule trips once when its condition appears and rearms when it clears.
+type SafetySupervisor struct {
+	o      *OIBot
+	config SafetyConfig
+	mu     sync.Mutex
+	subs   map[chan SafetyEvent]struct{}
+	active SafetyRule
+	stasis int
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func (o *OIBot) NewSafetySupervisor(config SafetyConfig) *SafetySupervisor {
+	if config.Period <= 0 {
+		config.Period = DefaultSafetyConfig.Period
+	}
+	if config.StasisSamples <= 0 {
+		config.StasisSamples = DefaultSafetyConfig.StasisSamples
+	}
+	return &SafetySupervisor{o: o, config: config, subs: map[chan SafetyEvent]struct{}{}}
+}
+
+// Start polls the hazard sensors every period until Stop is called, ctx is
+// done or the OIBot is closed. While a sensor stream is active polling is
+// impossible, so the stream's frames are checked instead, against whichever
+// rules its packets cover. A failed poll is reported once, in an event with no
+// Rule, and polling carries on.
+func (s *SafetySupervisor) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil != s.cancel {
+		return errors.New("safety supervisor already running")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	s.err = nil
+	go s.run(ctx, s.done)
+	return nil
+}
+
+func (s *SafetySupervisor) Stop() error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+	if nil != cancel {
+		cancel()
+		<-done
+	}
+	return s.Err()
+}
+
+// Err returns the error that ended polling, if any.
+func (s *SafetySupervisor) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *SafetySupervisor) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.config.Period)
+	defer ticker.Stop()
+	frames := make(chan *StreamFrame, 1)
+	var watched *SensorStream
+	failing := false
+	defer func() {
+		if nil != watched {
+			watched.unwatch(frames)
+		}
+	}()
+	for {
+		data, err := s.o.SensorListContext(ctx, safetyPacket...)
+		switch {
+		case nil != ctx.Err():
+			return
+		case errors.Is(err, ErrStreamActive):
+			if stream := s.o.activeStream(); stream != watched {
+				if nil != watched {
+					watched.unwatch(frames)
+				}
+				if watched = stream; nil != stream {
+					s.follow(stream, frames)
+				}
+			}
+		case errors.Is(err, ErrPortClosed):
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			return
+		case nil != err:
+			// the robot may be out of a mode that answers queries, or the
+			// link down, for a while; keep polling until it comes back.
+			if !failing {
+				s.notify(SafetyEvent{Time: time.Now(), Err: err})
+			}
+			failing = true
+		default:
+			failing = false
+			if nil != watched {
+				watched.unwatch(frames)
+				watched = nil
+			}
+			d := &SensorData{}
+			if err := d.DecodePackets(safetyPacket, data); nil == err {
+				s.Check(d)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case f := <-frames:
+			d := &SensorData{}
+			if err := d.DecodePackets(f.Packet, f.Data); nil == err {
+				s.check(d, streamRules(f.Packet))
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// follow starts checking the frames of stream, reporting any rules the
+// stream's packets leave unchecked.
+func (s *SafetySupervisor) follow(stream *SensorStream, frames chan<- *StreamFrame) {
+	if missing := s.config.Rules &^ streamRules(stream.Packets()); 0 != missing {
+		s.notify(SafetyEvent{Time: time.Now(), Err: fmt.Errorf(
+			"%w: stream lacks the packets to check %s", ErrStreamActive, missing)})
+	}
+	stream.watch(frames)
+}
+
+// streamRules returns the rules that can be checked from frames of packet.
+func streamRules(packet []*SensorPacket) SafetyRule {
+	var r SafetyRule
+	for _, p := range packet {
+		switch p {
+		case PacketBumpsWheeldrops:
+			r |= SafetyBump | SafetyWheelDrop
+		case PacketCliffLeft, PacketCliffFrontLeft, PacketCliffFrontRight, PacketCliffRight:
+			r |= SafetyCliff
+		case PacketOvercurrents:
+			r |= SafetyOvercurrent
+		case PacketStasis:
+			r |= SafetyStasis
+		}
+	}
+	return r
+}
+
+// Check applies the rules to a set of sensor readings, stopping the robot and
+// notifying subscribers if any newly trip, and returns the rules tripped.
+func (s *SafetySupervisor) Check(d *SensorData) SafetyRule {
+	return s.check(d, SafetyAll)
+}
+
+// check is Check restricted to rules, for readings that cover only some.
+func (s *SafetySupervisor) check(d *SensorData, rules SafetyRule) SafetyRule {
+	s.mu.Lock()
+	triggered := s.evaluate(d, rules&s.config.Rules)
+	trip := triggered &^ s.active
+	s.active = triggered
+	s.mu.Unlock()
+	if 0 == trip {
+		return 0
+	}
+	s.notify(SafetyEvent{Time: time.Now(), Rule: trip, Data: *d, Err: s.halt()})
+	return trip
+}
+
+func (s *SafetySupervisor) notify(event SafetyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- event:
+		default: // never let a slow subscriber delay the next check
+		}
+	}
+}
+
+// evaluate must be called with s.mu held.
+func (s *SafetySupervisor) evaluate(d *SensorData, rules SafetyRule) SafetyRule {
+	var r SafetyRule
+	if d.BumpsWheeldrops.Bump() {
+		r |= SafetyBump
+	}
+	if d.BumpsWheeldrops.WheelDrop() {
+		r |= SafetyWheelDrop
+	}
+	if d.CliffLeft || d.CliffFrontLeft || d.CliffFrontRight || d.CliffRight {
+		r |= SafetyCliff
+	}
+	if 0 != d.Overcurrents {
+		r |= SafetyOvercurrent
+	}
+	if 0 != rules&SafetyStasis && s.o.commandedVelocity() > 0 && 0 == d.Stasis&(StasisToggling|StasisDisabled) {
+		s.stasis++
+	} else {
+		s.stasis = 0
+	}
+	if s.stasis >= s.config.StasisSamples {
+		r |= SafetyStasis
+	}
+	return r & rules
+}
+
+// halt stops the wheels and the cleaning motors. Both are attempted even if
+// the first fails.
+func (s *SafetySupervisor) halt() error {
+	ctx := WithPriority(context.Background())
+	driveErr := haltError(s.o.DriveWheelsContext(ctx, 0, 0))
+	motorErr := haltError(s.o.PWMMotorsContext(ctx, 0, 0, 0))
+	if nil != driveErr {
+		return driveErr
+	}
+	return motorErr
+}
+
+func (s *SafetySupervisor) Subscribe() <-chan SafetyEvent {
+	ch := make(chan SafetyEvent, safetySubscriberBuffer)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *SafetySupervisor) Unsubscribe(ch <-chan SafetyEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.subs {
+		if c == ch {
+			delete(s.subs, c)
+			close(c)
+		}
+	}
+}
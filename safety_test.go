@@ -0,0 +1,110 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// expectSafety waits for the next event on ch.
+func expectSafety(t *testing.T, ch <-chan SafetyEvent) SafetyEvent {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no safety event")
+	}
+	return SafetyEvent{}
+}
+
+func TestSafetySupervisor(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMFull); nil != err {
+		t.Fatal(err)
+	}
+	s := o.NewSafetySupervisor(DefaultSafetyConfig)
+	ch := s.Subscribe()
+	if err := s.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	if err := o.Drive(100, StraightDriveRadiusMM); nil != err {
+		t.Fatal(err)
+	}
+	sim.Update(func(d *SensorData) { d.CliffFrontLeft = true })
+	if ev := expectSafety(t, ch); SafetyCliff != ev.Rule || nil != ev.Err {
+		t.Fatalf("event %s, %v; want %s", ev.Rule, ev.Err, SafetyCliff)
+	}
+	if err := s.Stop(); nil != err {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if v := sim.Sensors().VelocityLeftMMPS; 0 != v {
+		t.Fatalf("robot still driving at %d mm/s", v)
+	}
+}
+
+func TestSafetySupervisorStream(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if err := o.SetMode(OIMFull); nil != err {
+		t.Fatal(err)
+	}
+	stream, err := o.Stream(PacketBumpsWheeldrops, PacketCliffFrontLeft)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer stream.Stop()
+	go func() {
+		for range stream.C {
+		}
+	}()
+	s := o.NewSafetySupervisor(DefaultSafetyConfig)
+	ch := s.Subscribe()
+	if err := s.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	// the stream has no overcurrent or stasis packet
+	if ev := expectSafety(t, ch); 0 != ev.Rule || nil == ev.Err {
+		t.Fatalf("event %s, %v; want the unchecked rules reported", ev.Rule, ev.Err)
+	}
+	if err := o.Drive(100, StraightDriveRadiusMM); nil != err {
+		t.Fatal(err)
+	}
+	sim.Update(func(d *SensorData) { d.BumpsWheeldrops = BumpLeft })
+	if ev := expectSafety(t, ch); SafetyBump != ev.Rule || nil != ev.Err {
+		t.Fatalf("event %s, %v; want %s", ev.Rule, ev.Err, SafetyBump)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if v := sim.Sensors().VelocityLeftMMPS; 0 != v {
+		t.Fatalf("robot still driving at %d mm/s", v)
+	}
+}
+
+func TestSafetySupervisorOff(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	s := o.NewSafetySupervisor(DefaultSafetyConfig)
+	ch := s.Subscribe()
+	if err := s.Start(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := o.Stop(); nil != err {
+		t.Fatal(err)
+	}
+	if ev := expectSafety(t, ch); 0 != ev.Rule || !errors.Is(ev.Err, ErrInvalidMode) {
+		t.Fatalf("event %s, %v; want %v", ev.Rule, ev.Err, ErrInvalidMode)
+	}
+	// supervision resumes once the robot answers again
+	if err := o.SetMode(OIMFull); nil != err {
+		t.Fatal(err)
+	}
+	sim.Update(func(d *SensorData) { d.CliffFrontLeft = true })
+	if ev := expectSafety(t, ch); SafetyCliff != ev.Rule || nil != ev.Err {
+		t.Fatalf("event %s, %v; want %s", ev.Rule, ev.Err, SafetyCliff)
+	}
+	if err := s.Err(); nil != err {
+		t.Fatalf("Err() = %v", err)
+	}
+}
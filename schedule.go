@@ -0,0 +1,89 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type ScheduleTime struct {
+	Hour   int
+	Minute int
+}
+
+func (t ScheduleTime) validate() error {
+	if t.Hour < 0 || t.Hour > 23 || t.Minute < 0 || t.Minute > 59 {
+		return fmt.Errorf("%w: time %02d:%02d", ErrInvalidSchedule, t.Hour, t.Minute)
+	}
+	return nil
+}
+
+// Schedule maps each weekday that should have a cleaning to its start time.
+// Weekdays missing from the map are not scheduled.
+type Schedule map[time.Weekday]ScheduleTime
+
+// encode returns the Schedule command's payload: a weekday bitmask followed by
+// an hour and minute for each day, Sunday through Saturday.
+func (s Schedule) encode() ([]byte, error) {
+	data := make([]byte, 15)
+	for day, t := range s {
+		if day < time.Sunday || day > time.Saturday {
+			return nil, fmt.Errorf("%w: weekday %d", ErrInvalidSchedule, day)
+		}
+		if err := t.validate(); nil != err {
+			return nil, err
+		}
+		data[0] |= byte(WeekdayLED(day))
+		data[1+2*int(day)] = byte(t.Hour)
+		data[2+2*int(day)] = byte(t.Minute)
+	}
+	return data, nil
+}
+
+// =============================================================================
+
+// SetSchedule replaces the robot's cleaning schedule. The robot ignores it if
+// its own Schedule or Clock button is being pressed at the time.
+func (o *OIBot) SetSchedule(schedule Schedule) error {
+	return o.SetScheduleContext(context.Background(), schedule)
+}
+
+func (o *OIBot) SetScheduleContext(ctx context.Context, schedule Schedule) error {
+	data, err := schedule.encode()
+	if nil != err {
+		return err
+	}
+	return o.command(ctx, opcSchedule, data)
+}
+
+func (o *OIBot) ClearSchedule() error {
+	return o.ClearScheduleContext(context.Background())
+}
+
+func (o *OIBot) ClearScheduleContext(ctx context.Context) error {
+	return o.SetScheduleContext(ctx, nil)
+}
+
+func (o *OIBot) SetDayTime(day time.Weekday, hour int, minute int) error {
+	return o.SetDayTimeContext(context.Background(), day, hour, minute)
+}
+
+func (o *OIBot) SetDayTimeContext(ctx context.Context, day time.Weekday, hour int, minute int) error {
+	if day < time.Sunday || day > time.Saturday {
+		return fmt.Errorf("%w: weekday %d", ErrInvalidSchedule, day)
+	}
+	if err := (ScheduleTime{Hour: hour, Minute: minute}).validate(); nil != err {
+		return err
+	}
+	return o.command(ctx, opcSetDayTime, byte(day), byte(hour), byte(minute))
+}
+
+// SyncClock sets the robot's clock to the weekday, hour and minute of t, in
+// t's location.
+func (o *OIBot) SyncClock(t time.Time) error {
+	return o.SyncClockContext(context.Background(), t)
+}
+
+func (o *OIBot) SyncClockContext(ctx context.Context, t time.Time) error {
+	return o.SetDayTimeContext(ctx, t.Weekday(), t.Hour(), t.Minute())
+}
@@ -0,0 +1,58 @@
+package oibot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleEncode(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		schedule Schedule
+		want     []byte
+	}{
+		{"empty", nil, make([]byte, 15)},
+		{
+			"sunday",
+			Schedule{time.Sunday: {Hour: 9, Minute: 30}},
+			[]byte{0x01, 9, 30, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			"weekdays",
+			Schedule{
+				time.Monday:    {Hour: 8, Minute: 0},
+				time.Wednesday: {Hour: 12, Minute: 15},
+				time.Friday:    {Hour: 23, Minute: 59},
+			},
+			[]byte{0x2a, 0, 0, 8, 0, 0, 0, 12, 15, 0, 0, 23, 59, 0, 0},
+		},
+		{
+			"saturday",
+			Schedule{time.Saturday: {Hour: 0, Minute: 1}},
+			[]byte{0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		},
+	} {
+		got, err := test.schedule.encode()
+		if nil != err || !bytes.Equal(test.want, got) {
+			t.Errorf("%s: encode() = %v, %v; want %v", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestScheduleEncodeInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		schedule Schedule
+	}{
+		{"hour", Schedule{time.Monday: {Hour: 24}}},
+		{"minute", Schedule{time.Monday: {Minute: 60}}},
+		{"negative", Schedule{time.Monday: {Hour: -1}}},
+		{"weekday", Schedule{time.Weekday(7): {Hour: 8}}},
+	} {
+		if _, err := test.schedule.encode(); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%s: encode() = %v; want ErrInvalidSchedule", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package oibot
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// =============================================================================
+
+type BumpsWheeldrops byte
+
+const (
+	BumpRight      BumpsWheeldrops = 1 << 0
+	BumpLeft       BumpsWheeldrops = 1 << 1
+	WheelDropRight BumpsWheeldrops = 1 << 2
+	WheelDropLeft  BumpsWheeldrops = 1 << 3
+)
+
+func (b BumpsWheeldrops) Bump() bool      { return 0 != b&(BumpLeft|BumpRight) }
+func (b BumpsWheeldrops) WheelDrop() bool { return 0 != b&(WheelDropLeft|WheelDropRight) }
+
+type Overcurrents byte
+
+const (
+	OvercurrentSideBrush  Overcurrents = 1 << 0
+	OvercurrentMainBrush  Overcurrents = 1 << 2
+	OvercurrentRightWheel Overcurrents = 1 << 3
+	OvercurrentLeftWheel  Overcurrents = 1 << 4
+)
+
+type LightBumper byte
+
+const (
+	LightBumperLeft        LightBumper = 1 << 0
+	LightBumperFrontLeft   LightBumper = 1 << 1
+	LightBumperCenterLeft  LightBumper = 1 << 2
+	LightBumperCenterRight LightBumper = 1 << 3
+	LightBumperFrontRight  LightBumper = 1 << 4
+	LightBumperRight       LightBumper = 1 << 5
+)
+
+type Stasis byte
+
+const (
+	StasisToggling Stasis = 1 << 0
+	StasisDisabled Stasis = 1 << 1
+)
+
+// =============================================================================
+
+// SensorData holds the decoded value of every sensor packet 7 through 58.
+// Only the fields belonging to the packets actually decoded into it are
+// meaningful; the rest keep whatever value they had before.
+type SensorData struct {
+	BumpsWheeldrops         BumpsWheeldrops
+	Wall                    bool
+	CliffLeft               bool
+	CliffFrontLeft          bool
+	CliffFrontRight         bool
+	CliffRight              bool
+	VirtualWall             bool
+	Overcurrents            Overcurrents
+	DirtDetect              byte
+	IROpCode                byte
+	Buttons                 ButtonState
+	DistanceMM              int16
+	AngleDeg                int16
+	ChargingState           ChargingState
+	VoltagemV               uint16
+	CurrentmA               int16
+	TemperatureC            int8
+	BatteryChargemAh        uint16
+	BatteryCapacitymAh      uint16
+	WallSignal              uint16
+	CliffLeftSignal         uint16
+	CliffFrontLeftSignal    uint16
+	CliffFrontRightSignal   uint16
+	CliffRightSignal        uint16
+	ChargerAvailable        ChargerAvailable
+	Mode                    OpenInterfaceMode
+	SongNumber              byte
+	SongPlaying             bool
+	StreamNumPackets        byte
+	VelocityMMPS            int16
+	RadiusMM                int16
+	VelocityRightMMPS       int16
+	VelocityLeftMMPS        int16
+	EncoderCountsLeft       uint16
+	EncoderCountsRight      uint16
+	LightBumper             LightBumper
+	LightBumpLeft           uint16
+	LightBumpFrontLeft      uint16
+	LightBumpCenterLeft     uint16
+	LightBumpCenterRight    uint16
+	LightBumpFrontRight     uint16
+	LightBumpRight          uint16
+	IROpCodeLeft            byte
+	IROpCodeRight           byte
+	LeftMotorCurrentmA      int16
+	RightMotorCurrentmA     int16
+	MainBrushMotorCurrentmA int16
+	SideBrushMotorCurrentmA int16
+	Stasis                  Stasis
+}
+
+// DecodePacket stores the raw response for a single packet into d.
+func (d *SensorData) DecodePacket(packet *SensorPacket, data []byte) error {
+	if len(data) != int(packet.size) {
+		return fmt.Errorf("sensor packet %d: expected %d bytes, got %d", packet.id, packet.size, len(data))
+	}
+	u8 := data[0]
+	u16 := func() uint16 { return binary.BigEndian.Uint16(data) }
+	s16 := func() int16 { return int16(binary.BigEndian.Uint16(data)) }
+	switch packet {
+	case PacketBumpsWheeldrops:
+		d.BumpsWheeldrops = BumpsWheeldrops(u8)
+	case PacketWall:
+		d.Wall = 0 != u8
+	case PacketCliffLeft:
+		d.CliffLeft = 0 != u8
+	case PacketCliffFrontLeft:
+		d.CliffFrontLeft = 0 != u8
+	case PacketCliffFrontRight:
+		d.CliffFrontRight = 0 != u8
+	case PacketCliffRight:
+		d.CliffRight = 0 != u8
+	case PacketVirtualWall:
+		d.VirtualWall = 0 != u8
+	case PacketOvercurrents:
+		d.Overcurrents = Overcurrents(u8)
+	case PacketDirtDetect:
+		d.DirtDetect = u8
+	case PacketIROpCode:
+		d.IROpCode = u8
+	case PacketButtons:
+		d.Buttons = ButtonState(u8)
+	case PacketDistance:
+		d.DistanceMM = s16()
+	case PacketAngle:
+		d.AngleDeg = s16()
+	case PacketChargingState:
+		d.ChargingState = ChargingState(u8)
+	case PacketVoltage:
+		d.VoltagemV = u16()
+	case PacketCurrent:
+		d.CurrentmA = s16()
+	case PacketTemperature:
+		d.TemperatureC = int8(u8)
+	case PacketBatteryCharge:
+		d.BatteryChargemAh = u16()
+	case PacketBatteryCapacity:
+		d.BatteryCapacitymAh = u16()
+	case PacketWallSignal:
+		d.WallSignal = u16()
+	case PacketCliffLeftSignal:
+		d.CliffLeftSignal = u16()
+	case PacketCliffFrontLeftSignal:
+		d.CliffFrontLeftSignal = u16()
+	case PacketCliffFrontRightSignal:
+		d.CliffFrontRightSignal = u16()
+	case PacketCliffRightSignal:
+		d.CliffRightSignal = u16()
+	case PacketChargerAvailable:
+		d.ChargerAvailable = ChargerAvailable(u8)
+	case PacketOpenInterfaceMode:
+		d.Mode = OpenInterfaceMode(u8)
+	case PacketSongNumber:
+		d.SongNumber = u8
+	case PacketSongPlaying:
+		d.SongPlaying = 0 != u8
+	case PacketOIStreamNumPackets:
+		d.StreamNumPackets = u8
+	case PacketVelocity:
+		d.VelocityMMPS = s16()
+	case PacketRadius:
+		d.RadiusMM = s16()
+	case PacketVelocityRight:
+		d.VelocityRightMMPS = s16()
+	case PacketVelocityLeft:
+		d.VelocityLeftMMPS = s16()
+	case PacketEncoderCountsLeft:
+		d.EncoderCountsLeft = u16()
+	case PacketEncoderCountsRight:
+		d.EncoderCountsRight = u16()
+	case PacketLightBumper:
+		d.LightBumper = LightBumper(u8)
+	case PacketLightBumpLeft:
+		d.LightBumpLeft = u16()
+	case PacketLightBumpFrontLeft:
+		d.LightBumpFrontLeft = u16()
+	case PacketLightBumpCenterLeft:
+		d.LightBumpCenterLeft = u16()
+	case PacketLightBumpCenterRight:
+		d.LightBumpCenterRight = u16()
+	case PacketLightBumpFrontRight:
+		d.LightBumpFrontRight = u16()
+	case PacketLightBumpRight:
+		d.LightBumpRight = u16()
+	case PacketIROpCodeLeft:
+		d.IROpCodeLeft = u8
+	case PacketIROpCodeRight:
+		d.IROpCodeRight = u8
+	case PacketLeftMotorCurrent:
+		d.LeftMotorCurrentmA = s16()
+	case PacketRightMotorCurrent:
+		d.RightMotorCurrentmA = s16()
+	case PacketMainBrushCurrent:
+		d.MainBrushMotorCurrentmA = s16()
+	case PacketSideBrushCurrent:
+		d.SideBrushMotorCurrentmA = s16()
+	case PacketStasis:
+		d.Stasis = Stasis(u8)
+	case PacketUnused1, PacketUnused2, PacketUnused3:
+	default:
+		return fmt.Errorf("unknown sensor packet: %d", packet.id)
+	}
+	return nil
+}
+
+// DecodePackets stores the responses to a Query List (or the frames of a
+// stream) into d. packet and data must be parallel.
+func (d *SensorData) DecodePackets(packet []*SensorPacket, data [][]byte) error {
+	if len(packet) != len(data) {
+		return fmt.Errorf("expected %d sensor packets, got %d", len(packet), len(data))
+	}
+	for i, p := range packet {
+		if err := d.DecodePacket(p, data[i]); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeGroup stores the response to a group query (0-6, 100, 101, 106, 107)
+// into d.
+func (d *SensorData) DecodeGroup(group *SensorGroup, data []byte) error {
+	if len(data) != int(group.size) {
+		return fmt.Errorf("sensor group %d: expected %d bytes, got %d", group.id, group.size, len(data))
+	}
+	for _, p := range group.member {
+		if err := d.DecodePacket(p, data[:p.size]); nil != err {
+			return err
+		}
+		data = data[p.size:]
+	}
+	return nil
+}
+
+func DecodeSensorGroup(group *SensorGroup, data []byte) (*SensorData, error) {
+	d := &SensorData{}
+	if err := d.DecodeGroup(group, data); nil != err {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (f *StreamFrame) SensorData() (*SensorData, error) {
+	d := &SensorData{}
+	if err := d.DecodePackets(f.Packet, f.Data); nil != err {
+		return nil, err
+	}
+	return d, nil
+}
+
+// =============================================================================
+
+func (o *OIBot) SensorGroup(group *SensorGroup) ([]byte, error) {
+	return o.SensorGroupContext(context.Background(), group)
+}
+
+func (o *OIBot) SensorGroupContext(ctx context.Context, group *SensorGroup) ([]byte, error) {
+	return o.SensorContext(ctx, &SensorPacket{id: group.id, size: group.size})
+}
+
+func (o *OIBot) SensorData(group *SensorGroup) (*SensorData, error) {
+	return o.SensorDataContext(context.Background(), group)
+}
+
+func (o *OIBot) SensorDataContext(ctx context.Context, group *SensorGroup) (*SensorData, error) {
+	data, err := o.SensorGroupContext(ctx, group)
+	if nil != err {
+		return nil, err
+	}
+	d, err := DecodeSensorGroup(group, data)
+	if nil != err {
+		return nil, err
+	}
+	for _, p := range group.member {
+		if PacketOpenInterfaceMode == p {
+			o.observeMode(d.Mode)
+		}
+	}
+	return d, nil
+}
+
+// encodePacket is the inverse of DecodePacket, used by the simulator to answer
+// queries.
+func (d *SensorData) encodePacket(packet *SensorPacket) []byte {
+	data := make([]byte, packet.size)
+	u8 := func(v byte) { data[0] = v }
+	u16 := func(v uint16) { binary.BigEndian.PutUint16(data, v) }
+	s16 := func(v int16) { binary.BigEndian.PutUint16(data, uint16(v)) }
+	flag := func(v bool) {
+		if v {
+			data[0] = 1
+		}
+	}
+	switch packet {
+	case PacketBumpsWheeldrops:
+		u8(byte(d.BumpsWheeldrops))
+	case PacketWall:
+		flag(d.Wall)
+	case PacketCliffLeft:
+		flag(d.CliffLeft)
+	case PacketCliffFrontLeft:
+		flag(d.CliffFrontLeft)
+	case PacketCliffFrontRight:
+		flag(d.CliffFrontRight)
+	case PacketCliffRight:
+		flag(d.CliffRight)
+	case PacketVirtualWall:
+		flag(d.VirtualWall)
+	case PacketOvercurrents:
+		u8(byte(d.Overcurrents))
+	case PacketDirtDetect:
+		u8(d.DirtDetect)
+	case PacketIROpCode:
+		u8(d.IROpCode)
+	case PacketButtons:
+		u8(byte(d.Buttons))
+	case PacketDistance:
+		s16(d.DistanceMM)
+	case PacketAngle:
+		s16(d.AngleDeg)
+	case PacketChargingState:
+		u8(byte(d.ChargingState))
+	case PacketVoltage:
+		u16(d.VoltagemV)
+	case PacketCurrent:
+		s16(d.CurrentmA)
+	case PacketTemperature:
+		u8(byte(d.TemperatureC))
+	case PacketBatteryCharge:
+		u16(d.BatteryChargemAh)
+	case PacketBatteryCapacity:
+		u16(d.BatteryCapacitymAh)
+	case PacketWallSignal:
+		u16(d.WallSignal)
+	case PacketCliffLeftSignal:
+		u16(d.CliffLeftSignal)
+	case PacketCliffFrontLeftSignal:
+		u16(d.CliffFrontLeftSignal)
+	case PacketCliffFrontRightSignal:
+		u16(d.CliffFrontRightSignal)
+	case PacketCliffRightSignal:
+		u16(d.CliffRightSignal)
+	case PacketChargerAvailable:
+		u8(byte(d.ChargerAvailable))
+	case PacketOpenInterfaceMode:
+		u8(byte(d.Mode))
+	case PacketSongNumber:
+		u8(d.SongNumber)
+	case PacketSongPlaying:
+		flag(d.SongPlaying)
+	case PacketOIStreamNumPackets:
+		u8(d.StreamNumPackets)
+	case PacketVelocity:
+		s16(d.VelocityMMPS)
+	case PacketRadius:
+		s16(d.RadiusMM)
+	case PacketVelocityRight:
+		s16(d.VelocityRightMMPS)
+	case PacketVelocityLeft:
+		s16(d.VelocityLeftMMPS)
+	case PacketEncoderCountsLeft:
+		u16(d.EncoderCountsLeft)
+	case PacketEncoderCountsRight:
+		u16(d.EncoderCountsRight)
+	case PacketLightBumper:
+		u8(byte(d.LightBumper))
+	case PacketLightBumpLeft:
+		u16(d.LightBumpLeft)
+	case PacketLightBumpFrontLeft:
+		u16(d.LightBumpFrontLeft)
+	case PacketLightBumpCenterLeft:
+		u16(d.LightBumpCenterLeft)
+	case PacketLightBumpCenterRight:
+		u16(d.LightBumpCenterRight)
+	case PacketLightBumpFrontRight:
+		u16(d.LightBumpFrontRight)
+	case PacketLightBumpRight:
+		u16(d.LightBumpRight)
+	case PacketIROpCodeLeft:
+		u8(d.IROpCodeLeft)
+	case PacketIROpCodeRight:
+		u8(d.IROpCodeRight)
+	case PacketLeftMotorCurrent:
+		s16(d.LeftMotorCurrentmA)
+	case PacketRightMotorCurrent:
+		s16(d.RightMotorCurrentmA)
+	case PacketMainBrushCurrent:
+		s16(d.MainBrushMotorCurrentmA)
+	case PacketSideBrushCurrent:
+		s16(d.SideBrushMotorCurrentmA)
+	case PacketStasis:
+		u8(byte(d.Stasis))
+	}
+	return data
+}
@@ -0,0 +1,69 @@
+package oibot
+
+import "testing"
+
+func TestSensorGroupSizes(t *testing.T) {
+	for _, g := range []*SensorGroup{
+		GroupStatus, GroupObstacle, GroupDock, GroupBattery, GroupSignal,
+		GroupModeData, GroupSensor, GroupAll, GroupDrive, GroupProximity,
+		GroupActuator,
+	} {
+		size := 0
+		for _, p := range g.member {
+			size += int(p.size)
+		}
+		if int(g.size) != size {
+			t.Errorf("group %d: size %d, members total %d", g.id, g.size, size)
+		}
+	}
+}
+
+func TestDecodeSensorGroup(t *testing.T) {
+	for _, test := range []struct {
+		group *SensorGroup
+		data  []byte
+		want  SensorData
+	}{
+		{
+			group: GroupDock,
+			data:  []byte{0xa4, 0x01, 0xff, 0x9c, 0x00, 0x5a},
+			want:  SensorData{IROpCode: 0xa4, Buttons: 1, DistanceMM: -100, AngleDeg: 90},
+		},
+		{
+			group: GroupBattery,
+			data:  []byte{2, 0x3a, 0x98, 0xfc, 0x18, 0xe2, 0x0a, 0x8c, 0x0a, 0x90},
+			want: SensorData{
+				ChargingState: 2, VoltagemV: 15000, CurrentmA: -1000,
+				TemperatureC: -30, BatteryChargemAh: 2700, BatteryCapacitymAh: 2704,
+			},
+		},
+		{
+			group: GroupModeData,
+			data:  []byte{2, 3, 1, 0, 0xff, 0x38, 0x80, 0x00, 0x00, 0xc8, 0xff, 0x38},
+			want: SensorData{
+				Mode: OIMSafe, SongNumber: 3, SongPlaying: true, VelocityMMPS: -200,
+				RadiusMM: -32768, VelocityRightMMPS: 200, VelocityLeftMMPS: -200,
+			},
+		},
+		{
+			group: GroupActuator,
+			data:  []byte{0x00, 0x64, 0xff, 0x9c, 0x00, 0x00, 0x00, 0x05, 0x03},
+			want: SensorData{
+				LeftMotorCurrentmA: 100, RightMotorCurrentmA: -100,
+				SideBrushMotorCurrentmA: 5, Stasis: StasisToggling | StasisDisabled,
+			},
+		},
+	} {
+		d, err := DecodeSensorGroup(test.group, test.data)
+		if nil != err {
+			t.Errorf("group %d: %v", test.group.id, err)
+			continue
+		}
+		if test.want != *d {
+			t.Errorf("group %d: got %+v; want %+v", test.group.id, *d, test.want)
+		}
+	}
+	if _, err := DecodeSensorGroup(GroupDock, make([]byte, 5)); nil == err {
+		t.Error("a short response was decoded")
+	}
+}
@@ -0,0 +1,100 @@
+package oibot
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// bytePipe is one direction of an in-memory serial line. Unlike io.Pipe and
+// net.Pipe, writes never block: like a UART, the sender does not wait for the
+// receiver, and bytes queue up until they are read or flushed.
+type bytePipe struct {
+	mu       sync.Mutex
+	buf      []byte
+	closed   bool
+	deadline time.Time
+	wake     chan struct{}
+}
+
+func newBytePipe() *bytePipe {
+	return &bytePipe{wake: make(chan struct{})}
+}
+
+// signal wakes every blocked reader. Must be called with p.mu held.
+func (p *bytePipe) signal() {
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+func (p *bytePipe) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	p.buf = append(p.buf, b...)
+	p.signal()
+	return len(b), nil
+}
+
+func (p *bytePipe) Read(b []byte) (int, error) {
+	for {
+		p.mu.Lock()
+		if len(p.buf) > 0 {
+			n := copy(b, p.buf)
+			p.buf = p.buf[n:]
+			p.mu.Unlock()
+			return n, nil
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return 0, io.EOF
+		}
+		var timer *time.Timer
+		var expire <-chan time.Time
+		if !p.deadline.IsZero() {
+			wait := time.Until(p.deadline)
+			if wait <= 0 {
+				p.mu.Unlock()
+				return 0, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(wait)
+			expire = timer.C
+		}
+		wake := p.wake
+		p.mu.Unlock()
+		select {
+		case <-wake:
+		case <-expire:
+		}
+		if nil != timer {
+			timer.Stop()
+		}
+	}
+}
+
+func (p *bytePipe) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deadline = t
+	p.signal()
+	return nil
+}
+
+func (p *bytePipe) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = nil
+}
+
+func (p *bytePipe) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		p.signal()
+	}
+	return nil
+}
@@ -0,0 +1,590 @@
+package oibot
+
+import (
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	simCapacitymAh      float64 = 2696
+	simIdleCurrentmA    float64 = 180
+	simDriveCurrentmA   float64 = 1.2 // per mm/s of mean wheel speed
+	simMotorCurrentmA   float64 = 300 // per cleaning motor at full duty cycle
+	simChargeCurrentmA  float64 = 1500
+	simTrickleCurrentmA float64 = 50
+	simEmptyVoltagemV   float64 = 13000
+	simFullVoltagemV    float64 = 16800
+	simButtonHold               = time.Second / 6
+	simDockTime                 = 3 * time.Second
+)
+
+// simCommandArgs is the number of argument bytes following each opcode with a
+// fixed-length payload. Song, Query List and Stream carry their own lengths.
+var simCommandArgs = map[OpCode]int{
+	opcBaud: 1, opcDrive: 4, opcMotors: 1, opcLEDs: 3, opcPlay: 1,
+	opcQuery: 1, opcPWMMotors: 3, opcDriveWheels: 4, opcDrivePWM: 4,
+	opcDoStream: 1, opcSchedulingLEDs: 2, opcDigitLEDsRaw: 4,
+	opcDigitLEDsASCII: 4, opcButtons: 1, opcSchedule: 15, opcSetDayTime: 3,
+}
+
+// Simulator is an in-process Create 2 that speaks the Open Interface byte
+// protocol over an in-memory serial line. It tracks OI mode, wheel kinematics,
+// encoder counts, battery drain and charging, songs and the digit display, and
+// answers Query, Query List and Stream requests with correctly sized packets:
+//
+//	sim := NewSimulator()
+//	defer sim.Close()
+//	o, err := MakeOIBotTransport(infoLog, errorLog, false, sim.Transport(), DefaultBaudRateBPS, DefaultReadTimeoutMS)
+//
+// Environmental inputs (bumps, cliffs, walls, IR, the charger) are injected
+// with Update. As on the real robot, a wheel drop, or a cliff while moving,
+// drops Safe mode back to Passive.
+type Simulator struct {
+	mu         sync.Mutex
+	rx, tx     *bytePipe
+	host       *simTransport
+	baud       int
+	hostBaud   int
+	data       SensorData
+	x, y       float64
+	theta      float64
+	vr, vl     float64
+	encL, encR float64
+	dist       float64
+	angle      float64
+	charge     float64
+	motors     [3]int8
+	songs      [SongSlots]Song
+	songEnd    time.Time
+	buttonsEnd time.Time
+	display    string
+	stream     []byte
+	streaming  bool
+	dockTime   time.Duration
+	seekStart  time.Time
+	last       time.Time
+	done       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func NewSimulator() *Simulator {
+	s := &Simulator{
+		rx:       newBytePipe(),
+		tx:       newBytePipe(),
+		baud:     DefaultBaudRateBPS,
+		hostBaud: DefaultBaudRateBPS,
+		charge:   0.8 * simCapacitymAh,
+		last:     time.Now(),
+		done:     make(chan struct{}),
+		dockTime: simDockTime,
+	}
+	s.data.Mode = OIMOff
+	s.data.TemperatureC = 25
+	s.data.BatteryCapacitymAh = uint16(simCapacitymAh)
+	s.host = &simTransport{sim: s, tx: s.tx}
+	s.advance(s.last)
+	s.wg.Add(2)
+	go s.serve()
+	go s.tick()
+	return s
+}
+
+// Transport returns the host end of the simulated serial line.
+func (s *Simulator) Transport() Transport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.host
+}
+
+// Unplug breaks the serial line, as pulling the cable would: the host end
+// returned by Transport so far reads EOF and fails to write, while the robot
+// carries on. Transport then returns a new, connected host end.
+func (s *Simulator) Unplug() {
+	s.mu.Lock()
+	old := s.host
+	s.tx = newBytePipe()
+	s.host = &simTransport{sim: s, tx: s.tx}
+	s.mu.Unlock()
+	old.unplug()
+}
+
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		return nil
+	default:
+		close(s.done)
+	}
+	tx := s.tx
+	s.mu.Unlock()
+	s.rx.Close()
+	tx.Close()
+	s.wg.Wait()
+	return nil
+}
+
+// Update lets fn modify the simulated sensor state, e.g. to press a bumper or
+// put the robot on its dock. Fields the simulator computes itself (encoders,
+// velocities, distance and angle, mode) are overwritten on the next step, but
+// battery charge and capacity set here are carried forward.
+func (s *Simulator) Update(fn func(d *SensorData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(time.Now())
+	fn(&s.data)
+	s.charge = float64(s.data.BatteryChargemAh)
+	s.advance(time.Now())
+}
+
+// Sensors returns a snapshot of the simulated sensor state.
+func (s *Simulator) Sensors() SensorData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(time.Now())
+	return s.data
+}
+
+// Pose returns the robot's position in millimeters and heading in radians
+// (counterclockwise positive) relative to where it started.
+func (s *Simulator) Pose() (x float64, y float64, theta float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(time.Now())
+	return s.x, s.y, s.theta
+}
+
+func (s *Simulator) Mode() OpenInterfaceMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Mode
+}
+
+// SetDockTime sets how long Force Seeking Dock takes to reach the Home Base.
+// Zero means the dock is never found.
+func (s *Simulator) SetDockTime(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dockTime = d
+}
+
+// SetBaud changes the rate the robot listens and answers at, as pulsing its
+// Baud Rate Change pin or a reset would, without telling the host.
+func (s *Simulator) SetBaud(baud int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baud = baud
+}
+
+// Display returns the text last written to the digit LEDs with the ASCII
+// command.
+func (s *Simulator) Display() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.display
+}
+
+// =============================================================================
+
+func (s *Simulator) serve() {
+	defer s.wg.Done()
+	for {
+		op, err := s.read(1)
+		if nil != err {
+			return
+		}
+		if err := s.execute(OpCode(op[0])); nil != err {
+			return
+		}
+	}
+}
+
+func (s *Simulator) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(s.rx, buf)
+	return buf, err
+}
+
+func (s *Simulator) execute(op OpCode) error {
+	var args []byte
+	switch op {
+	case opcSong:
+		head, err := s.read(2)
+		if nil != err {
+			return err
+		}
+		notes, err := s.read(2 * int(head[1]))
+		if nil != err {
+			return err
+		}
+		args = append(head, notes...)
+	case opcQueryList, opcStream:
+		head, err := s.read(1)
+		if nil != err {
+			return err
+		}
+		id, err := s.read(int(head[0]))
+		if nil != err {
+			return err
+		}
+		args = append(head, id...)
+	default:
+		if n, ok := simCommandArgs[op]; ok {
+			var err error
+			if args, err = s.read(n); nil != err {
+				return err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.advance(now)
+
+	mode := s.data.Mode
+	if OIMOff == mode && opcStart != op && opcReset != op {
+		return nil
+	}
+	drive := OIMSafe == mode || OIMFull == mode
+	s16 := func(i int) int16 { return int16(uint16(args[i])<<8 | uint16(args[i+1])) }
+
+	switch op {
+	case opcStart:
+		s.setMode(OIMPassive)
+	case opcReset:
+		s.setMode(OIMOff)
+		s.streaming = false
+		s.baud = DefaultBaudRateBPS
+	case opcStop:
+		s.setMode(OIMOff)
+		s.streaming = false
+	case opcBaud:
+		for rate, code := range codeForBaudRate {
+			if BaudRateCode(args[0]) == code {
+				s.baud = rate
+			}
+		}
+	case opcControl, opcSafe:
+		s.setMode(OIMSafe)
+	case opcFull:
+		s.setMode(OIMFull)
+	case opcPower, opcSpot, opcClean, opcMaxClean:
+		s.setMode(OIMPassive)
+	case opcForceSeekingDock:
+		s.setMode(OIMPassive)
+		s.seekStart = now
+	case opcDrive:
+		if drive {
+			s.drive(s16(0), s16(2))
+		}
+	case opcDriveWheels:
+		if drive {
+			s.vr, s.vl = float64(s16(0)), float64(s16(2))
+			s.data.VelocityMMPS, s.data.RadiusMM = int16((s.vr+s.vl)/2), 0
+		}
+	case opcDrivePWM:
+		if drive {
+			scale := float64(MaxDriveVelocityMMPS) / float64(MaxDrivePWM)
+			s.vr, s.vl = scale*float64(s16(0)), scale*float64(s16(2))
+			s.data.VelocityMMPS, s.data.RadiusMM = int16((s.vr+s.vl)/2), 0
+		}
+	case opcMotors:
+		if drive {
+			s.motors = [3]int8{}
+			if 0 != Motors(args[0])&MotorMainBrush {
+				s.motors[0] = MaxBrushPWM
+			}
+			if 0 != Motors(args[0])&MotorSideBrush {
+				s.motors[1] = MaxBrushPWM
+			}
+			if 0 != Motors(args[0])&MotorVacuum {
+				s.motors[2] = MaxVacuumPWM
+			}
+		}
+	case opcPWMMotors:
+		if drive {
+			s.motors = [3]int8{int8(args[0]), int8(args[1]), int8(args[2])}
+		}
+	case opcDigitLEDsASCII:
+		if drive {
+			s.display = string(args)
+		}
+	case opcDigitLEDsRaw:
+		if drive {
+			s.display = ""
+		}
+	case opcSong:
+		if int(args[0]) < SongSlots && args[1] > 0 && int(args[1]) <= MaxSongNotes {
+			song := make(Song, args[1])
+			for i := range song {
+				song[i] = Note{Number: args[2+2*i], Duration: args[3+2*i]}
+			}
+			s.songs[args[0]] = song
+		}
+	case opcPlay:
+		if drive && int(args[0]) < SongSlots && nil != s.songs[args[0]] {
+			s.songEnd = now.Add(s.songs[args[0]].Length())
+			s.data.SongNumber = args[0]
+			s.data.SongPlaying = true
+		}
+	case opcButtons:
+		s.data.Buttons = ButtonState(args[0])
+		s.buttonsEnd = now.Add(simButtonHold)
+	case opcQuery:
+		s.send(s.sensor(args[0]))
+	case opcQueryList:
+		var reply []byte
+		for _, id := range args[1:] {
+			reply = append(reply, s.sensor(id)...)
+		}
+		s.send(reply)
+	case opcStream:
+		s.stream = append([]byte(nil), args[1:]...)
+		s.streaming = true
+		s.data.StreamNumPackets = args[0]
+	case opcDoStream:
+		s.streaming = streamStateResume == args[0] && len(s.stream) > 0
+	}
+	return nil
+}
+
+func (s *Simulator) setMode(mode OpenInterfaceMode) {
+	s.seekStart = time.Time{}
+	if OIMSafe != mode && OIMFull != mode {
+		s.drive(0, 0)
+		s.motors = [3]int8{}
+	}
+	s.data.Mode = mode
+}
+
+// drive converts a velocity and turn radius into wheel velocities, with the
+// Drive command's special radii for straight lines and turns in place.
+func (s *Simulator) drive(velocity int16, radius int16) {
+	v, r, b := float64(velocity), float64(radius), float64(DriveWheelSeparationMM)
+	switch {
+	case StraightDriveRadiusMM == radius || -0x8000 == radius || 0 == radius:
+		s.vr, s.vl = v, v
+	case 1 == radius:
+		s.vr, s.vl = v, -v
+	case -1 == radius:
+		s.vr, s.vl = -v, v
+	default:
+		s.vr, s.vl = v*(r+b/2)/r, v*(r-b/2)/r
+	}
+	s.data.VelocityMMPS, s.data.RadiusMM = velocity, radius
+}
+
+// sensor returns the response to a query for a packet or group ID. Unknown IDs
+// get no response, as on the robot.
+func (s *Simulator) sensor(id byte) []byte {
+	if g, ok := groupByID[id]; ok {
+		var data []byte
+		for _, p := range g.member {
+			data = append(data, s.packet(p)...)
+		}
+		return data
+	}
+	if p, ok := packetByID[id]; ok {
+		return s.packet(p)
+	}
+	return nil
+}
+
+func (s *Simulator) packet(p *SensorPacket) []byte {
+	// distance and angle count from the previous time they were requested
+	switch p {
+	case PacketDistance:
+		s.data.DistanceMM = clampInt16(s.dist)
+		s.dist -= float64(s.data.DistanceMM)
+	case PacketAngle:
+		s.data.AngleDeg = clampInt16(s.angle)
+		s.angle -= float64(s.data.AngleDeg)
+	}
+	return s.data.encodePacket(p)
+}
+
+// send writes data to the host, unless the two ends disagree on baud rate, in
+// which case the bytes are lost to framing errors.
+func (s *Simulator) send(data []byte) {
+	if len(data) > 0 && s.baud == s.hostBaud {
+		s.tx.Write(data)
+	}
+}
+
+func (s *Simulator) tick() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(SensorUpdateDelayMS)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			s.advance(now)
+			if s.streaming && OIMOff != s.data.Mode {
+				s.send(s.streamFrame())
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Simulator) streamFrame() []byte {
+	frame := []byte{streamHeader, 0}
+	for _, id := range s.stream {
+		if data := s.sensor(id); nil != data {
+			frame = append(frame, id)
+			frame = append(frame, data...)
+		}
+	}
+	frame[1] = byte(len(frame) - 2)
+	var sum byte
+	for _, b := range frame {
+		sum += b
+	}
+	return append(frame, -sum)
+}
+
+// advance integrates the robot's state forward to now.
+func (s *Simulator) advance(now time.Time) {
+	dt := now.Sub(s.last).Seconds()
+	if dt < 0 {
+		dt = 0
+	}
+	s.last = now
+	d := &s.data
+
+	// differential drive kinematics
+	dl, dr := s.vl*dt, s.vr*dt
+	dc, dth := (dl+dr)/2, (dr-dl)/float64(DriveWheelSeparationMM)
+	s.x += dc * math.Cos(s.theta+dth/2)
+	s.y += dc * math.Sin(s.theta+dth/2)
+	s.theta = math.Remainder(s.theta+dth, 2*math.Pi)
+	s.dist += dc
+	s.angle += dth * 180 / math.Pi
+	countsPerMM := EncoderCountsPerRev / (math.Pi * WheelDiameterMM)
+	s.encL += dl * countsPerMM
+	s.encR += dr * countsPerMM
+	d.EncoderCountsLeft = uint16(int64(math.Floor(s.encL)))
+	d.EncoderCountsRight = uint16(int64(math.Floor(s.encR)))
+	d.VelocityLeftMMPS, d.VelocityRightMMPS = int16(s.vl), int16(s.vr)
+	d.LeftMotorCurrentmA, d.RightMotorCurrentmA = int16(s.vl/2), int16(s.vr/2)
+	d.MainBrushMotorCurrentmA = int16(float64(s.motors[0]) * simMotorCurrentmA / float64(MaxBrushPWM))
+	d.SideBrushMotorCurrentmA = int16(float64(s.motors[1]) * simMotorCurrentmA / float64(MaxBrushPWM))
+	if dc > 0 {
+		d.Stasis |= StasisToggling
+	} else {
+		d.Stasis &^= StasisToggling
+	}
+
+	// battery drain, or charging when docked and passive
+	capacity := float64(d.BatteryCapacitymAh)
+	currentmA := -(simIdleCurrentmA + simDriveCurrentmA*(math.Abs(s.vl)+math.Abs(s.vr))/2)
+	for _, pwm := range s.motors {
+		currentmA -= simMotorCurrentmA * math.Abs(float64(pwm)) / float64(MaxBrushPWM)
+	}
+	d.ChargingState = ChargingStateNotCharging
+	if 0 != d.ChargerAvailable && OIMPassive == d.Mode {
+		if s.charge < 0.99*capacity {
+			currentmA, d.ChargingState = simChargeCurrentmA, ChargingStateFull
+		} else {
+			currentmA, d.ChargingState = simTrickleCurrentmA, ChargingStateTrickle
+		}
+	}
+	s.charge = math.Max(0, math.Min(capacity, s.charge+currentmA*dt/3600))
+	d.CurrentmA = int16(currentmA)
+	d.BatteryChargemAh = uint16(s.charge)
+	d.VoltagemV = uint16(simEmptyVoltagemV)
+	if capacity > 0 {
+		d.VoltagemV = uint16(simEmptyVoltagemV + (simFullVoltagemV-simEmptyVoltagemV)*s.charge/capacity)
+	}
+
+	// seeking the dock: the buoys come into view, then the charging contacts
+	if !s.seekStart.IsZero() {
+		d.IROpCode = IRDockRedBuoy | IRDockGreenBuoy
+		d.IROpCodeLeft, d.IROpCodeRight = d.IROpCode, d.IROpCode
+		if s.dockTime > 0 && now.Sub(s.seekStart) >= s.dockTime {
+			d.ChargerAvailable |= ChargerHomeBase
+			d.IROpCode, d.IROpCodeLeft, d.IROpCodeRight = 0, 0, 0
+			s.seekStart = time.Time{}
+		}
+	}
+
+	if d.SongPlaying && now.After(s.songEnd) {
+		d.SongPlaying = false
+	}
+	if 0 != d.Buttons && now.After(s.buttonsEnd) {
+		d.Buttons = 0
+	}
+
+	// Safe mode protects the robot from falling: a wheel drop, or a cliff
+	// while moving, drops it back to Passive.
+	cliff := d.CliffLeft || d.CliffFrontLeft || d.CliffFrontRight || d.CliffRight
+	if OIMSafe == d.Mode && (d.BumpsWheeldrops.WheelDrop() || (cliff && (0 != s.vl || 0 != s.vr))) {
+		s.setMode(OIMPassive)
+	}
+}
+
+func clampInt16(v float64) int16 {
+	return int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Trunc(v))))
+}
+
+// =============================================================================
+
+type simTransport struct {
+	sim       *Simulator
+	tx        *bytePipe
+	unplugged int32
+}
+
+func (t *simTransport) unplug() {
+	atomic.StoreInt32(&t.unplugged, 1)
+	t.tx.Close()
+}
+
+func (t *simTransport) Read(buf []byte) (int, error) {
+	return t.tx.Read(buf)
+}
+
+func (t *simTransport) Write(buf []byte) (int, error) {
+	if 0 != atomic.LoadInt32(&t.unplugged) {
+		return 0, io.ErrClosedPipe
+	}
+	t.sim.mu.Lock()
+	match := t.sim.baud == t.sim.hostBaud
+	t.sim.mu.Unlock()
+	if !match {
+		return len(buf), nil // lost to framing errors
+	}
+	return t.sim.rx.Write(buf)
+}
+
+func (t *simTransport) Flush() error {
+	t.tx.Flush()
+	return nil
+}
+
+// Close shuts down the simulator, unless this end has been unplugged.
+func (t *simTransport) Close() error {
+	if 0 != atomic.LoadInt32(&t.unplugged) {
+		return nil
+	}
+	return t.sim.Close()
+}
+
+func (t *simTransport) SetBaud(baud int) error {
+	t.sim.mu.Lock()
+	defer t.sim.mu.Unlock()
+	t.sim.hostBaud = baud
+	return nil
+}
+
+func (t *simTransport) SetReadDeadline(deadline time.Time) error {
+	return t.tx.SetReadDeadline(deadline)
+}
@@ -0,0 +1,90 @@
+package oibot
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// newSimBot returns an OIBot talking to a fresh Simulator over port, or over
+// the simulator's own transport if port is nil.
+func newSimBot(t *testing.T, port func(Transport) Transport) (*OIBot, *Simulator) {
+	t.Helper()
+	sim := NewSimulator()
+	t.Cleanup(func() { sim.Close() })
+	host := sim.Transport()
+	if nil != port {
+		host = port(host)
+	}
+	l := log.New(io.Discard, "", 0)
+	o, err := MakeOIBotTransport(l, l, false, host, DefaultBaudRateBPS, DefaultReadTimeoutMS)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return o, sim
+}
+
+func TestSimulatorQuery(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	if mode, err := o.Mode(); nil != err || OIMPassive != mode {
+		t.Fatalf("Mode() = %v, %v; want %v", mode, err, OIMPassive)
+	}
+	sim.Update(func(d *SensorData) {
+		d.BumpsWheeldrops = BumpRight
+		d.Wall = true
+	})
+	time.Sleep(2 * SensorUpdateDelayMS)
+	d, err := o.SensorData(GroupAll)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !d.BumpsWheeldrops.Bump() || !d.Wall || OIMPassive != d.Mode {
+		t.Fatalf("SensorData(GroupAll) = %+v", d)
+	}
+	b, err := o.Battery()
+	if nil != err || uint16(simCapacitymAh) != b.BatteryCapacitymAh {
+		t.Fatalf("Battery() = %+v, %v", b, err)
+	}
+	data, err := o.SensorList(PacketWall, PacketOpenInterfaceMode)
+	if nil != err || 1 != data[0][0] || byte(OIMPassive) != data[1][0] {
+		t.Fatalf("SensorList() = %v, %v", data, err)
+	}
+}
+
+func TestSimulatorCommands(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	for _, step := range []struct {
+		command func() error
+		want    OpenInterfaceMode
+	}{
+		{o.Safe, OIMSafe},
+		{o.Full, OIMFull},
+		{o.Passive, OIMPassive},
+		{o.Safe, OIMSafe},
+	} {
+		if err := step.command(); nil != err {
+			t.Fatal(err)
+		}
+		// the query also waits for the robot to act on the command
+		if got, err := o.Mode(); nil != err || step.want != got {
+			t.Fatalf("Mode() = %v, %v; want %v", got, err, step.want)
+		}
+		if got := sim.Mode(); step.want != got {
+			t.Fatalf("robot in %v; want %v", got, step.want)
+		}
+	}
+	if err := o.DriveWheels(150, 100); nil != err {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if d := sim.Sensors(); 150 != d.VelocityRightMMPS || 100 != d.VelocityLeftMMPS {
+		t.Fatalf("robot driving at %d, %d mm/s; want 150, 100", d.VelocityRightMMPS, d.VelocityLeftMMPS)
+	}
+	// a wheel drop in Safe mode stops the robot and falls back to Passive
+	sim.Update(func(d *SensorData) { d.BumpsWheeldrops = WheelDropLeft })
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if mode, err := o.Mode(); nil != err || OIMPassive != mode || 0 != sim.Sensors().VelocityLeftMMPS {
+		t.Fatalf("after a wheel drop: Mode() = %v, %v", mode, err)
+	}
+}
@@ -0,0 +1,261 @@
+package oibot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SongSlots           int  = 4
+	MaxSongNotes        int  = 16
+	MinNoteNumber       byte = 31 // G1
+	MaxNoteNumber       byte = 127
+	RestNote            byte = 0 // any number outside Min..MaxNoteNumber rests
+	NoteTicksPerSecond  int  = 64
+	DefaultSongTempoBPM int  = 120
+)
+
+// Note is a MIDI note number held for Duration/64 seconds.
+type Note struct {
+	Number   byte
+	Duration byte
+}
+
+func (n Note) IsRest() bool {
+	return n.Number < MinNoteNumber || n.Number > MaxNoteNumber
+}
+
+func (n Note) Length() time.Duration {
+	return time.Duration(n.Duration) * time.Second / time.Duration(NoteTicksPerSecond)
+}
+
+type Song []Note
+
+func (s Song) Length() time.Duration {
+	var d time.Duration
+	for _, n := range s {
+		d += n.Length()
+	}
+	return d
+}
+
+// split breaks s into pieces that each fit in one song slot.
+func (s Song) split() []Song {
+	var part []Song
+	for len(s) > MaxSongNotes {
+		part = append(part, s[:MaxSongNotes])
+		s = s[MaxSongNotes:]
+	}
+	if len(s) > 0 {
+		part = append(part, s)
+	}
+	return part
+}
+
+// =============================================================================
+
+var noteSemitone = map[byte]int{
+	'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11,
+}
+
+// ParseSong reads a song written as whitespace-separated notes at the default
+// tempo. See ParseSongTempo.
+func ParseSong(notation string) (Song, error) {
+	return ParseSongTempo(notation, DefaultSongTempoBPM)
+}
+
+// ParseSongTempo reads a song written as whitespace-separated notes of the
+// form <pitch>[/<value>], where pitch is a letter A-G, an optional sharp (#)
+// or flat (b), and an octave number (C4 is middle C, MIDI 60), or R for a
+// rest. value is the note's fraction of a whole note (1, 2, 4, 8, ...), with a
+// trailing dot for dotted notes, and defaults to a quarter note. For example:
+//
+//	C4/8 D#4/16 R/8 G4/4.
+func ParseSongTempo(notation string, bpm int) (Song, error) {
+	if bpm <= 0 {
+		return nil, fmt.Errorf("%w: tempo: %d", ErrInvalidSong, bpm)
+	}
+	var song Song
+	for _, token := range strings.Fields(notation) {
+		n, err := parseNote(token, bpm)
+		if nil != err {
+			return nil, err
+		}
+		song = append(song, n)
+	}
+	return song, nil
+}
+
+func parseNote(token string, bpm int) (Note, error) {
+	pitch, value := token, "4"
+	if i := strings.IndexByte(token, '/'); i >= 0 {
+		pitch, value = token[:i], token[i+1:]
+	}
+	var note Note
+	if strings.EqualFold(pitch, "R") {
+		note.Number = RestNote
+	} else {
+		number, err := parsePitch(pitch)
+		if nil != err {
+			return note, fmt.Errorf("%w: %q: %s", ErrInvalidSong, token, err)
+		}
+		note.Number = number
+	}
+	dotted := strings.HasSuffix(value, ".")
+	div, err := strconv.Atoi(strings.TrimSuffix(value, "."))
+	if nil != err || div <= 0 {
+		return note, fmt.Errorf("%w: %q: invalid note value", ErrInvalidSong, token)
+	}
+	// a whole note is four beats
+	ticks := 4 * 60 * NoteTicksPerSecond / (bpm * div)
+	if dotted {
+		ticks += ticks / 2
+	}
+	if ticks < 1 || ticks > 255 {
+		return note, fmt.Errorf("%w: %q: duration of %d/64 s out of range", ErrInvalidSong, token, ticks)
+	}
+	note.Duration = byte(ticks)
+	return note, nil
+}
+
+func parsePitch(pitch string) (byte, error) {
+	if len(pitch) < 2 {
+		return 0, fmt.Errorf("missing octave")
+	}
+	semi, ok := noteSemitone[byte(strings.ToUpper(pitch[:1])[0])]
+	if !ok {
+		return 0, fmt.Errorf("unknown note name")
+	}
+	rest := pitch[1:]
+	switch rest[0] {
+	case '#':
+		semi, rest = semi+1, rest[1:]
+	case 'b':
+		semi, rest = semi-1, rest[1:]
+	}
+	octave, err := strconv.Atoi(rest)
+	if nil != err {
+		return 0, fmt.Errorf("invalid octave")
+	}
+	number := 12*(octave+1) + semi
+	if number < int(MinNoteNumber) || number > int(MaxNoteNumber) {
+		return 0, fmt.Errorf("MIDI note %d outside %d-%d", number, MinNoteNumber, MaxNoteNumber)
+	}
+	return byte(number), nil
+}
+
+// =============================================================================
+
+func (o *OIBot) DefineSong(slot int, song Song) error {
+	return o.DefineSongContext(context.Background(), slot, song)
+}
+
+func (o *OIBot) DefineSongContext(ctx context.Context, slot int, song Song) error {
+	data, err := encodeSong(slot, song)
+	if nil != err {
+		return err
+	}
+	return o.command(ctx, opcSong, data)
+}
+
+func encodeSong(slot int, song Song) ([]byte, error) {
+	if err := validateSongSlot(slot); nil != err {
+		return nil, err
+	}
+	if 0 == len(song) || len(song) > MaxSongNotes {
+		return nil, fmt.Errorf("%w: %d notes", ErrInvalidSong, len(song))
+	}
+	data := []byte{byte(slot), byte(len(song))}
+	for _, n := range song {
+		data = append(data, n.Number, n.Duration)
+	}
+	return data, nil
+}
+
+func validateSongSlot(slot int) error {
+	if slot < 0 || slot >= SongSlots {
+		return fmt.Errorf("%w: slot %d", ErrInvalidSong, slot)
+	}
+	return nil
+}
+
+func (o *OIBot) PlaySong(slot int) error {
+	return o.PlaySongContext(context.Background(), slot)
+}
+
+func (o *OIBot) PlaySongContext(ctx context.Context, slot int) error {
+	if err := validateSongSlot(slot); nil != err {
+		return err
+	}
+	return o.command(ctx, opcPlay, byte(slot))
+}
+
+func (o *OIBot) SongPlaying() (bool, error) {
+	return o.SongPlayingContext(context.Background())
+}
+
+func (o *OIBot) SongPlayingContext(ctx context.Context) (bool, error) {
+	data, err := o.SensorContext(ctx, PacketSongPlaying)
+	if nil != err {
+		return false, err
+	}
+	return 0 != data[0], nil
+}
+
+func (o *OIBot) Play(song Song) error {
+	return o.PlayContext(context.Background(), song)
+}
+
+// PlayContext plays a song of any length, blocking until it has finished. Songs
+// longer than MaxSongNotes are split across the song slots, with each piece
+// loaded while the previous one plays and started once the Song Playing
+// sensor reports the robot has gone quiet.
+func (o *OIBot) PlayContext(ctx context.Context, song Song) error {
+	part := song.split()
+	if 0 == len(part) {
+		return nil
+	}
+	if err := o.DefineSongContext(ctx, 0, part[0]); nil != err {
+		return err
+	}
+	for i, p := range part {
+		slot := i % SongSlots
+		if err := o.PlaySongContext(ctx, slot); nil != err {
+			return err
+		}
+		started := time.Now()
+		if i+1 < len(part) {
+			if err := o.DefineSongContext(ctx, (i+1)%SongSlots, part[i+1]); nil != err {
+				return err
+			}
+		}
+		if err := o.awaitSong(ctx, started, p.Length()); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OIBot) awaitSong(ctx context.Context, started time.Time, length time.Duration) error {
+	// no point asking before the song could possibly be over
+	if err := sleepContext(ctx, time.Until(started.Add(length-SensorUpdateDelayMS))); nil != err {
+		return err
+	}
+	deadline := started.Add(length + time.Second)
+	for time.Now().Before(deadline) {
+		playing, err := o.SongPlayingContext(ctx)
+		if nil != err {
+			return err
+		}
+		if !playing {
+			return nil
+		}
+		if err := sleepContext(ctx, SensorUpdateDelayMS); nil != err {
+			return err
+		}
+	}
+	return nil
+}
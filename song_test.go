@@ -0,0 +1,99 @@
+package oibot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePitch(t *testing.T) {
+	for _, test := range []struct {
+		pitch string
+		want  byte
+		ok    bool
+	}{
+		{"C4", 60, true},
+		{"c4", 60, true},
+		{"C#4", 61, true},
+		{"Db4", 61, true},
+		{"Cb4", 59, true},
+		{"B3", 59, true},
+		{"bb3", 58, true},
+		{"G1", MinNoteNumber, true},
+		{"G9", MaxNoteNumber, true},
+		{"F#1", 0, false},
+		{"G#9", 0, false},
+		{"C", 0, false},
+		{"H4", 0, false},
+		{"C#", 0, false},
+		{"Cx", 0, false},
+	} {
+		got, err := parsePitch(test.pitch)
+		if test.ok != (nil == err) || (test.ok && test.want != got) {
+			t.Errorf("parsePitch(%q) = %d, %v; want %d", test.pitch, got, err, test.want)
+		}
+	}
+}
+
+func TestParseSongTempo(t *testing.T) {
+	for _, test := range []struct {
+		notation string
+		bpm      int
+		want     Song
+		ok       bool
+	}{
+		{"C4", 120, Song{{60, 32}}, true},
+		{"C4/8 D#4/16 R/8 G4/4. Bb3", 120, Song{{60, 16}, {63, 8}, {RestNote, 16}, {67, 48}, {58, 32}}, true},
+		{"  C4/1\tr/2\n", 120, Song{{60, 128}, {RestNote, 64}}, true},
+		{"C4/32", 120, Song{{60, 4}}, true},
+		{"C4/4", 60, Song{{60, 64}}, true},
+		{"", 120, nil, true},
+		{"C4/1", 60, nil, false},    // 256 ticks
+		{"C4/128", 240, nil, false}, // half a tick
+		{"C4/0", 120, nil, false},
+		{"C4/x", 120, nil, false},
+		{"C0", 120, nil, false},
+		{"C4", 0, nil, false},
+	} {
+		got, err := ParseSongTempo(test.notation, test.bpm)
+		if !test.ok {
+			if !errors.Is(err, ErrInvalidSong) {
+				t.Errorf("ParseSongTempo(%q, %d) = %v, %v; want ErrInvalidSong", test.notation, test.bpm, got, err)
+			}
+			continue
+		}
+		if nil != err || len(test.want) != len(got) {
+			t.Errorf("ParseSongTempo(%q, %d) = %v, %v; want %v", test.notation, test.bpm, got, err, test.want)
+			continue
+		}
+		for i := range got {
+			if test.want[i] != got[i] {
+				t.Errorf("ParseSongTempo(%q, %d) = %v; want %v", test.notation, test.bpm, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSongSplit(t *testing.T) {
+	for _, test := range []struct {
+		notes int
+		want  []int
+	}{
+		{0, nil},
+		{1, []int{1}},
+		{MaxSongNotes, []int{MaxSongNotes}},
+		{MaxSongNotes + 1, []int{MaxSongNotes, 1}},
+		{2*MaxSongNotes + 1, []int{MaxSongNotes, MaxSongNotes, 1}},
+	} {
+		part := make(Song, test.notes).split()
+		if len(test.want) != len(part) {
+			t.Errorf("split() of %d notes: %d parts; want %d", test.notes, len(part), len(test.want))
+			continue
+		}
+		for i := range part {
+			if test.want[i] != len(part[i]) {
+				t.Errorf("split() of %d notes: part %d has %d notes; want %d", test.notes, i, len(part[i]), test.want[i])
+			}
+		}
+	}
+}
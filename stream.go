@@ -0,0 +1,368 @@
+package oibot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Every stream frame has the layout:
+//
+//	[19] [N-bytes] [Packet ID 1] [Data 1] ... [Packet ID k] [Data k] [Checksum]
+//
+// where N-bytes counts the IDs and data between it and the checksum, and the
+// low byte of the sum of every byte in the frame (checksum included) is zero.
+const (
+	streamHeader      byte = 19
+	streamStateResume byte = 1
+	streamStatePause  byte = 0
+	streamFrameBuffer int  = 8
+)
+
+type StreamFrame struct {
+	Time   time.Time
+	Packet []*SensorPacket
+	Data   [][]byte
+}
+
+func (f *StreamFrame) Get(packet *SensorPacket) ([]byte, bool) {
+	for i, p := range f.Packet {
+		if p == packet {
+			return f.Data[i], true
+		}
+	}
+	return nil, false
+}
+
+// =============================================================================
+
+type streamParser struct {
+	packet  []*SensorPacket
+	length  byte
+	buf     []byte
+	dropped uint64
+}
+
+// newStreamParser fails if the packets would not fit in a single frame, whose
+// N-bytes field is one byte wide.
+func newStreamParser(packet []*SensorPacket) (*streamParser, error) {
+	length := 0
+	for _, p := range packet {
+		length += 1 + int(p.size)
+	}
+	if length > 255 {
+		return nil, fmt.Errorf("stream frame of %d bytes exceeds the 255 allowed", length)
+	}
+	return &streamParser{packet: packet, length: byte(length)}, nil
+}
+
+// feed appends raw bytes from the port and returns every complete, valid
+// frame found so far. Bytes that cannot begin a valid frame are discarded one
+// at a time, so a corrupted frame costs at most itself and parsing resumes at
+// the next header byte.
+func (p *streamParser) feed(data []byte) [][][]byte {
+	var frames [][][]byte
+	p.buf = append(p.buf, data...)
+	start := 0
+	for {
+		i := bytes.IndexByte(p.buf[start:], streamHeader)
+		if i < 0 {
+			start = len(p.buf)
+			break
+		}
+		start += i
+		buf := p.buf[start:]
+		if len(buf) < 2 {
+			break
+		}
+		if buf[1] != p.length {
+			start++
+			continue
+		}
+		total := 3 + int(p.length)
+		if len(buf) < total {
+			break
+		}
+		if frame, ok := p.decode(buf[:total]); ok {
+			frames = append(frames, frame)
+			start += total
+		} else {
+			p.dropped++
+			start++
+		}
+	}
+	p.buf = append(p.buf[:0], p.buf[start:]...)
+	return frames
+}
+
+func (p *streamParser) decode(buf []byte) ([][]byte, bool) {
+	var sum byte
+	for _, b := range buf {
+		sum += b
+	}
+	if 0 != sum {
+		return nil, false
+	}
+	frame := make([][]byte, len(p.packet))
+	for i, j := 0, 2; i < len(p.packet); i++ {
+		if buf[j] != p.packet[i].id {
+			return nil, false
+		}
+		j++
+		frame[i] = append([]byte(nil), buf[j:j+int(p.packet[i].size)]...)
+		j += int(p.packet[i].size)
+	}
+	return frame, true
+}
+
+// =============================================================================
+
+type SensorStream struct {
+	C <-chan *StreamFrame
+
+	o         *OIBot
+	packet    []*SensorPacket
+	request   []byte
+	parser    *streamParser
+	modeIndex int
+	frames    chan *StreamFrame
+	cancel    context.CancelFunc
+	done      chan struct{}
+	mu        sync.Mutex
+	paused    bool
+	err       error
+	dropped   uint64
+	watchers  map[chan<- *StreamFrame]struct{}
+}
+
+func (o *OIBot) Stream(packet ...*SensorPacket) (*SensorStream, error) {
+	return o.StreamContext(context.Background(), packet...)
+}
+
+// StreamContext asks the robot to send the given packets every
+// SensorUpdateDelayMS and delivers each checksum-valid frame on the returned
+// stream's channel. The stream runs until Stop is called or ctx is done, after
+// which the channel is closed. While a stream is active the port belongs to
+// it, and Sensor and SensorList fail with ErrStreamActive.
+func (o *OIBot) StreamContext(ctx context.Context, packet ...*SensorPacket) (*SensorStream, error) {
+	if 0 == len(packet) || len(packet) > 255 {
+		return nil, fmt.Errorf("invalid number of stream packets: %d", len(packet))
+	}
+	parser, err := newStreamParser(packet)
+	if nil != err {
+		return nil, err
+	}
+	baud := o.BaudRate()
+	if frameBits := bitsPerByte * (3 + int(parser.length)); time.Duration(frameBits)*time.Second/time.Duration(baud) > SensorUpdateDelayMS {
+		return nil, fmt.Errorf("stream frame of %d bytes cannot be sent every %s at %d baud", 3+int(parser.length), SensorUpdateDelayMS, baud)
+	}
+	request := append([]byte{byte(len(packet))}, o.sensorListID(packet...)...)
+	s := &SensorStream{
+		o:         o,
+		packet:    packet,
+		request:   request,
+		parser:    parser,
+		frames:    make(chan *StreamFrame, streamFrameBuffer),
+		done:      make(chan struct{}),
+		modeIndex: -1,
+	}
+	for i, p := range packet {
+		if PacketOpenInterfaceMode == p {
+			s.modeIndex = i
+		}
+	}
+	s.C = s.frames
+	// claim the port in the same job that starts the stream, so that no query
+	// can be answered with stream frames.
+	err = o.queue.do(ctx, func(ctx context.Context) error {
+		o.mu.Lock()
+		active := nil != o.stream
+		o.mu.Unlock()
+		if active {
+			return ErrStreamActive
+		}
+		o.transport().Flush()
+		if _, err := o.write(ctx, opcStream, request); nil != err {
+			return err
+		}
+		o.mu.Lock()
+		o.stream = s
+		o.mu.Unlock()
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+	return s, nil
+}
+
+func (s *SensorStream) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.frames)
+	defer func() {
+		// the robot streams until told otherwise, so quiet it down before
+		// handing the port back for ordinary queries.
+		s.o.WriteContext(context.Background(), opcDoStream, streamStatePause)
+		s.o.Flush()
+		s.o.mu.Lock()
+		s.o.stream = nil
+		s.o.mu.Unlock()
+	}()
+	chunk := make([]byte, 256)
+	for {
+		err := s.receive(ctx, chunk)
+		if nil != ctx.Err() {
+			return
+		}
+		// a Reconnector restarts the stream on the new port once the link is
+		// back, so the stream outlives the outage.
+		if up := s.o.outage(); nil != up {
+			select {
+			case <-up:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		return
+	}
+}
+
+// receive delivers frames from the port until ctx is done or a read fails.
+func (s *SensorStream) receive(ctx context.Context, chunk []byte) error {
+	release := interruptRead(ctx, s.o.transport())
+	defer release()
+	for nil == ctx.Err() {
+		n, err := s.o.read(chunk)
+		if n > 0 {
+			now := time.Now()
+			frames := s.parser.feed(chunk[:n])
+			atomic.StoreUint64(&s.dropped, s.parser.dropped)
+			for _, data := range frames {
+				if i := s.modeIndex; i >= 0 {
+					s.o.observeMode(OpenInterfaceMode(data[i][0]))
+				}
+				frame := &StreamFrame{Time: now, Packet: s.packet, Data: data}
+				s.mu.Lock()
+				for ch := range s.watchers {
+					select {
+					case ch <- frame:
+					default:
+					}
+				}
+				s.mu.Unlock()
+				select {
+				case s.frames <- frame:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		if nil != err && ErrTimeout != err { // a paused stream is expected to go quiet
+			return err
+		}
+	}
+	return nil
+}
+
+// restart asks the robot for the stream again, as it was, after the link has
+// been reestablished. A stream stopped during the outage is left stopped.
+func (s *SensorStream) restart(ctx context.Context) error {
+	paused := s.Paused()
+	return s.o.queue.do(ctx, func(ctx context.Context) error {
+		if s.o.activeStream() != s {
+			return nil
+		}
+		s.o.transport().Flush()
+		if _, err := s.o.write(ctx, opcStream, s.request); nil != err {
+			return err
+		}
+		if paused {
+			_, err := s.o.write(ctx, opcDoStream, []byte{streamStatePause})
+			return err
+		}
+		return nil
+	})
+}
+
+// watch has every frame also offered to ch, for observers such as the safety
+// supervisor that must not take frames from the stream's own channel. A frame
+// ch has no room for is skipped.
+func (s *SensorStream) watch(ch chan<- *StreamFrame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil == s.watchers {
+		s.watchers = map[chan<- *StreamFrame]struct{}{}
+	}
+	s.watchers[ch] = struct{}{}
+}
+
+func (s *SensorStream) unwatch(ch chan<- *StreamFrame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watchers, ch)
+}
+
+func (s *SensorStream) Pause() error {
+	return s.PauseContext(context.Background())
+}
+
+func (s *SensorStream) PauseContext(ctx context.Context) error {
+	return s.setState(ctx, streamStatePause)
+}
+
+func (s *SensorStream) Resume() error {
+	return s.ResumeContext(context.Background())
+}
+
+func (s *SensorStream) ResumeContext(ctx context.Context) error {
+	return s.setState(ctx, streamStateResume)
+}
+
+func (s *SensorStream) setState(ctx context.Context, state byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.o.WriteContext(ctx, opcDoStream, state); nil != err {
+		return err
+	}
+	s.paused = streamStatePause == state
+	return nil
+}
+
+func (s *SensorStream) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// Stop halts the stream on the robot, closes the frame channel and returns
+// the error, if any, that terminated the stream early.
+func (s *SensorStream) Stop() error {
+	s.cancel()
+	<-s.done
+	return s.Err()
+}
+
+func (s *SensorStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of frames discarded for a bad checksum or an
+// unexpected layout.
+func (s *SensorStream) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *SensorStream) Packets() []*SensorPacket {
+	return s.packet
+}
@@ -0,0 +1,92 @@
+package oibot
+
+import (
+	"testing"
+	"time"
+)
+
+// frameOf builds a stream frame around payload with a valid checksum.
+func frameOf(payload ...byte) []byte {
+	frame := append([]byte{streamHeader, byte(len(payload))}, payload...)
+	var sum byte
+	for _, b := range frame {
+		sum += b
+	}
+	return append(frame, -sum)
+}
+
+func TestStreamParserResync(t *testing.T) {
+	p, err := newStreamParser([]*SensorPacket{PacketBumpsWheeldrops, PacketVoltage})
+	if nil != err {
+		t.Fatal(err)
+	}
+	good := frameOf(7, 3, 22, 0x3a, 0x98)
+	bad := append([]byte(nil), good...)
+	bad[3] = 9 // corrupt the bumps, leaving the checksum as it was
+
+	// noise, stray header bytes and a corrupted frame ahead of a good one,
+	// and the start of another good one
+	in := append([]byte{1, 2, streamHeader, streamHeader}, bad...)
+	in = append(in, good...)
+	in = append(in, good[:4]...)
+	frames := p.feed(in)
+	if 1 != len(frames) || 3 != frames[0][0][0] || 0x98 != frames[0][1][1] {
+		t.Fatalf("feed() = %v; want the one good frame", frames)
+	}
+	if frames = p.feed(good[4:]); 1 != len(frames) {
+		t.Fatalf("feed() of the rest of a frame = %v", frames)
+	}
+	if 1 != p.dropped {
+		t.Fatalf("dropped = %d; want 1", p.dropped)
+	}
+}
+
+func TestStreamParserTooLong(t *testing.T) {
+	packet := make([]*SensorPacket, 10)
+	for i := range packet {
+		packet[i] = PacketLightBumpLeft
+	}
+	if _, err := newStreamParser(packet); nil != err {
+		t.Fatalf("10 packets of 2 bytes: %v", err)
+	}
+	packet = make([]*SensorPacket, 90)
+	for i := range packet {
+		packet[i] = PacketLightBumpLeft
+	}
+	if _, err := newStreamParser(packet); nil == err {
+		t.Fatal("a frame of 270 bytes was accepted")
+	}
+}
+
+func TestStream(t *testing.T) {
+	o, sim := newSimBot(t, nil)
+	s, err := o.Stream(PacketEncoderCountsLeft, PacketOpenInterfaceMode)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := o.Sensor(PacketWall); ErrStreamActive != err {
+		t.Fatalf("Sensor() while streaming = %v; want ErrStreamActive", err)
+	}
+	timeout := time.After(time.Second)
+	for n := 0; n < 5; n++ {
+		select {
+		case f := <-s.C:
+			if mode, ok := f.Get(PacketOpenInterfaceMode); !ok || byte(OIMPassive) != mode[0] {
+				t.Fatalf("frame = %v", f.Data)
+			}
+		case <-timeout:
+			t.Fatalf("only %d frames in a second", n)
+		}
+	}
+	if err := s.Stop(); nil != err {
+		t.Fatal(err)
+	}
+	if 0 != s.Dropped() {
+		t.Fatalf("Dropped() = %d", s.Dropped())
+	}
+	sim.Update(func(d *SensorData) { d.Wall = true })
+	time.Sleep(2 * SensorUpdateDelayMS)
+	if data, err := o.Sensor(PacketWall); nil != err || 1 != data[0] {
+		t.Fatalf("Sensor() after Stop = %v, %v", data, err)
+	}
+}
@@ -0,0 +1,178 @@
+package oibot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// Transport is the byte link between the host and the robot. Any serial
+// library, pty, pipe or network bridge can drive an OIBot by satisfying it.
+type Transport interface {
+	io.ReadWriteCloser
+	Flush() error
+	SetBaud(baud int) error
+}
+
+// =============================================================================
+
+type serialTransport struct {
+	mu     sync.Mutex
+	port   *serial.Port // nil while closed
+	config *serial.Config
+}
+
+func OpenSerialTransport(path string, baud int, rtime time.Duration) (Transport, error) {
+	config := &serial.Config{Name: path, Baud: baud}
+	if rtime > NeverReadTimeoutMS {
+		config.ReadTimeout = rtime
+	}
+	port, err := serial.OpenPort(config)
+	if nil != err {
+		return nil, fmt.Errorf("failed to open serial port: %s (%d): %w", path, baud, err)
+	}
+	return &serialTransport{port: port, config: config}, nil
+}
+
+func (s *serialTransport) open() (*serial.Port, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil == s.port {
+		return nil, os.ErrClosed
+	}
+	return s.port, nil
+}
+
+func (s *serialTransport) Read(buf []byte) (int, error) {
+	port, err := s.open()
+	if nil != err {
+		return 0, err
+	}
+	return port.Read(buf)
+}
+
+func (s *serialTransport) Write(buf []byte) (int, error) {
+	port, err := s.open()
+	if nil != err {
+		return 0, err
+	}
+	return port.Write(buf)
+}
+
+func (s *serialTransport) Flush() error {
+	port, err := s.open()
+	if nil != err {
+		return err
+	}
+	return port.Flush()
+}
+
+// Close closes the port; closing it again, or after a failed SetBaud, is a
+// no-op.
+func (s *serialTransport) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil == s.port {
+		return nil
+	}
+	port := s.port
+	s.port = nil
+	return port.Close()
+}
+
+// SetBaud reopens the underlying port at the new rate; tarm/serial has no way
+// to reconfigure an open port in place. If the port cannot be reopened it is
+// left closed, and SetBaud may be called again to retry.
+func (s *serialTransport) SetBaud(baud int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nil != s.port {
+		err := s.port.Close()
+		s.port = nil
+		if nil != err {
+			return fmt.Errorf("failed to close serial port: %w", err)
+		}
+	}
+	config := *s.config
+	config.Baud = baud
+	port, err := serial.OpenPort(&config)
+	if nil != err {
+		return fmt.Errorf("failed to open serial port: %s (%d): %w", config.Name, baud, err)
+	}
+	s.port, s.config = port, &config
+	return nil
+}
+
+// =============================================================================
+
+type streamTransport struct {
+	io.ReadWriteCloser
+}
+
+// WrapTransport adapts a plain io.ReadWriteCloser into a Transport. Flush and
+// SetBaud are no-ops, which suits pipes, ptys with fixed settings, and network
+// bridges that handle the serial side themselves.
+func WrapTransport(rwc io.ReadWriteCloser) Transport {
+	if t, ok := rwc.(Transport); ok {
+		return t
+	}
+	return &streamTransport{ReadWriteCloser: rwc}
+}
+
+func (s *streamTransport) Flush() error {
+	return nil
+}
+
+func (s *streamTransport) SetBaud(baud int) error {
+	return nil
+}
+
+func (s *streamTransport) SetReadDeadline(t time.Time) error {
+	if d, ok := s.ReadWriteCloser.(readDeadliner); ok {
+		return d.SetReadDeadline(t)
+	}
+	return errNoDeadline
+}
+
+// =============================================================================
+
+// readDeadliner is implemented by transports such as net.Conn and *os.File
+// (ptys, pipes) whose blocking reads can be interrupted from another goroutine.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+var errNoDeadline = errors.New("transport does not support read deadlines")
+
+// interruptRead arranges for a read blocked on t to return as soon as ctx is
+// done. The returned release func must be called once reading has finished;
+// it restores the transport's deadline if it was changed.
+func interruptRead(ctx context.Context, t Transport) (release func()) {
+	d, ok := t.(readDeadliner)
+	if !ok || nil == ctx.Done() {
+		return func() {}
+	}
+	done, exited := make(chan struct{}), make(chan struct{})
+	fired := false
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			fired = nil == d.SetReadDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+		if fired {
+			d.SetReadDeadline(time.Time{})
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package oibot
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/tarm/serial"
+)
+
+func TestSerialTransportReopenFailed(t *testing.T) {
+	s := &serialTransport{config: &serial.Config{Name: "/nonexistent/tty", Baud: DefaultBaudRateBPS}}
+	for i := 0; i < 2; i++ {
+		if err := s.SetBaud(57600); nil == err || errors.Is(err, os.ErrClosed) {
+			t.Fatalf("SetBaud() on a missing device = %v", err)
+		}
+	}
+	if _, err := s.Write([]byte{byte(opcStart)}); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Write() after a failed reopen = %v", err)
+	}
+	if err := s.Close(); nil != err {
+		t.Fatalf("Close() after a failed reopen = %v", err)
+	}
+}